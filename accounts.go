@@ -0,0 +1,55 @@
+package fbapi
+
+import (
+	"context"
+	"fmt"
+)
+
+// Account is a Page managed by a user. The AccessToken is the Page access
+// token, it is a secret and is redacted when the Account is formatted.
+type Account struct {
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	AccessToken string   `json:"access_token"`
+	Category    string   `json:"category"`
+	Perms       []string `json:"perms"`
+}
+
+func (a Account) String() string {
+	a.AccessToken = RedactToken(a.AccessToken)
+	type account Account
+	return fmt.Sprintf("%+v", account(a))
+}
+
+// GoString redacts the AccessToken for the %#v verb.
+func (a Account) GoString() string {
+	a.AccessToken = RedactToken(a.AccessToken)
+	type account Account
+	return fmt.Sprintf("%#v", account(a))
+}
+
+// Accounts fetches all the pages of the /me/accounts edge, which lists the
+// Pages managed by the user along with their access tokens.
+func (c *Client) Accounts(ctx context.Context, params ...Param) ([]Account, error) {
+	var page struct {
+		Data   []Account `json:"data"`
+		Paging *Paging   `json:"paging"`
+	}
+	if _, err := c.get(ctx, "me/accounts", &page, params...); err != nil {
+		return nil, err
+	}
+
+	accounts := page.Data
+	for {
+		page.Data = nil
+		paging, ok, err := c.Next(ctx, page.Paging, &page)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return accounts, nil
+		}
+		page.Paging = paging
+		accounts = append(accounts, page.Data...)
+	}
+}
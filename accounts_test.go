@@ -0,0 +1,102 @@
+package fbapi_test
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/facebookgo/ensure"
+	"github.com/facebookgo/fbapi"
+)
+
+const accountsPage1 = `{
+  "data": [
+    {
+      "access_token": "page-token-1",
+      "category": "Product/Service",
+      "name": "Page One",
+      "id": "1",
+      "perms": ["ADMINISTER", "EDIT_PROFILE"]
+    }
+  ],
+  "paging": {
+    "cursors": {"before": "MQ", "after": "MQ"},
+    "next": "https://graph.facebook.com/me/accounts?access_token=at&limit=1&after=MQ"
+  }
+}`
+
+const accountsPage2 = `{
+  "data": [
+    {
+      "access_token": "page-token-2",
+      "category": "Community",
+      "name": "Page Two",
+      "id": "2",
+      "perms": ["CREATE_CONTENT"]
+    }
+  ],
+  "paging": {
+    "cursors": {"before": "Mg", "after": "Mg"}
+  }
+}`
+
+func TestAccounts(t *testing.T) {
+	t.Parallel()
+	var calls int
+	c := &fbapi.Client{
+		Transport: fTransport(func(r *http.Request) (*http.Response, error) {
+			calls++
+			body := accountsPage1
+			if calls == 1 {
+				ensure.DeepEqual(t, r.URL.String(), "https://graph.facebook.com/me/accounts?access_token=at&limit=1")
+			} else {
+				ensure.DeepEqual(t, r.URL.String(), "https://graph.facebook.com/me/accounts?access_token=at&limit=1&after=MQ")
+				body = accountsPage2
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(strings.NewReader(body)),
+			}, nil
+		}),
+	}
+	accounts, err := c.Accounts(context.Background(), fbapi.ParamAccessToken("at"), fbapi.ParamLimit(1))
+	ensure.Nil(t, err)
+	ensure.DeepEqual(t, accounts, []fbapi.Account{
+		{
+			ID:          "1",
+			Name:        "Page One",
+			AccessToken: "page-token-1",
+			Category:    "Product/Service",
+			Perms:       []string{"ADMINISTER", "EDIT_PROFILE"},
+		},
+		{
+			ID:          "2",
+			Name:        "Page Two",
+			AccessToken: "page-token-2",
+			Category:    "Community",
+			Perms:       []string{"CREATE_CONTENT"},
+		},
+	})
+}
+
+func TestAccountRedacted(t *testing.T) {
+	t.Parallel()
+	a := fbapi.Account{ID: "1", Name: "Page One", AccessToken: "secret"}
+	for _, format := range []string{"%s", "%v", "%+v", "%#v"} {
+		s := fmt.Sprintf(format, a)
+		ensure.False(t, strings.Contains(s, "secret"), s)
+		ensure.True(t, strings.Contains(s, "REDACTED"), s)
+		ensure.True(t, strings.Contains(s, "Page One"), s)
+	}
+	s := fmt.Sprintf("%v", []fbapi.Account{a})
+	ensure.False(t, strings.Contains(s, "secret"), s)
+}
+
+func TestRedactToken(t *testing.T) {
+	t.Parallel()
+	ensure.DeepEqual(t, fbapi.RedactToken("secret"), "REDACTED")
+	ensure.DeepEqual(t, fbapi.RedactToken(""), "")
+}
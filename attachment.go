@@ -0,0 +1,26 @@
+package fbapi
+
+import "net/url"
+
+// Attachment is one of the links of a multi-link post, such as a carousel.
+type Attachment struct {
+	Link        string `json:"link"`
+	Name        string `json:"name,omitempty"`
+	Description string `json:"description,omitempty"`
+	ImageHash   string `json:"image_hash,omitempty"`
+}
+
+type paramChildAttachments []Attachment
+
+func (p paramChildAttachments) Set(values url.Values) error {
+	if len(p) == 0 {
+		return nil
+	}
+	return paramJSON{key: "child_attachments", value: []Attachment(p)}.Set(values)
+}
+
+// ParamChildAttachments specifies the child_attachments parameter for
+// multi-link posts. Empty lists are not sent.
+func ParamChildAttachments(attachments []Attachment) Param {
+	return paramChildAttachments(attachments)
+}
@@ -0,0 +1,42 @@
+package fbapi_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/facebookgo/ensure"
+	"github.com/facebookgo/fbapi"
+)
+
+func TestParamChildAttachments(t *testing.T) {
+	t.Parallel()
+	v, err := fbapi.ParamValues(fbapi.ParamChildAttachments([]fbapi.Attachment{
+		{
+			Link:        "https://example.com/a",
+			Name:        "A",
+			Description: "The first",
+			ImageHash:   "abc",
+		},
+		{Link: "https://example.com/b"},
+	}))
+	ensure.Nil(t, err)
+
+	var actual []map[string]string
+	ensure.Nil(t, json.Unmarshal([]byte(v.Get("child_attachments")), &actual))
+	ensure.DeepEqual(t, actual, []map[string]string{
+		{
+			"link":        "https://example.com/a",
+			"name":        "A",
+			"description": "The first",
+			"image_hash":  "abc",
+		},
+		{"link": "https://example.com/b"},
+	})
+}
+
+func TestParamChildAttachmentsEmpty(t *testing.T) {
+	t.Parallel()
+	v, err := fbapi.ParamValues(fbapi.ParamChildAttachments(nil))
+	ensure.Nil(t, err)
+	ensure.DeepEqual(t, len(v), 0)
+}
@@ -0,0 +1,76 @@
+package fbapi
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// AppSecretProof computes the appsecret_proof for the given access token,
+// which is the hex encoded HMAC-SHA256 of the token keyed by the app secret.
+func AppSecretProof(token, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(token))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Set the access_token query parameter from the TokenSource or AccessToken
+// unless the request already has one, and the appsecret_proof for it. The URL
+// is replaced rather than modified as it may be shared.
+func (c *Client) setAuthParams(req *http.Request) error {
+	query := req.URL.Query()
+	changed := false
+
+	if query.Get("access_token") == "" {
+		var p Param
+		switch {
+		case c.TokenSource != nil:
+			p = ParamTokenSource(c.TokenSource)
+		case c.AccessToken != "":
+			p = ParamAccessToken(c.AccessToken)
+		}
+		if p != nil {
+			if err := p.Set(query); err != nil {
+				return err
+			}
+			changed = true
+		}
+	}
+
+	token := query.Get("access_token")
+	if c.AppSecret != "" && token != "" && query.Get("appsecret_proof") == "" {
+		query.Set("appsecret_proof", AppSecretProof(token, c.AppSecret))
+		changed = true
+	}
+
+	if changed {
+		u := *req.URL
+		u.RawQuery = query.Encode()
+		req.URL = &u
+	}
+	return nil
+}
+
+// Returns err with any occurrences of the secret, as is or query escaped,
+// redacted from its message. An *Error remains one, other errors lose their
+// type only if they include the secret.
+func redactError(err error, secret string) error {
+	if err == nil || secret == "" {
+		return err
+	}
+	r := strings.NewReplacer(secret, redacted, url.QueryEscape(secret), redacted)
+	if apiErr, ok := err.(*Error); ok {
+		redactedErr := *apiErr
+		redactedErr.Message = r.Replace(apiErr.Message)
+		return &redactedErr
+	}
+	msg := r.Replace(err.Error())
+	if msg == err.Error() {
+		return err
+	}
+	return errors.New(msg)
+}
@@ -0,0 +1,90 @@
+package fbapi_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/facebookgo/ensure"
+	"github.com/facebookgo/fbapi"
+)
+
+const tokenSecretProof = "e941110e3d2bfe82621f0e3e1434730d7305d106c5f68c87165d0b27a4611a4a"
+
+func TestAppSecretProof(t *testing.T) {
+	t.Parallel()
+	ensure.DeepEqual(t, fbapi.AppSecretProof("token", "secret"), tokenSecretProof)
+}
+
+func TestAppSecretProofParam(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		Client   *fbapi.Client
+		Query    string
+		Expected url.Values
+	}{
+		{
+			Client: &fbapi.Client{AppSecret: "secret"},
+			Query:  "access_token=token",
+			Expected: url.Values{
+				"access_token":    []string{"token"},
+				"appsecret_proof": []string{tokenSecretProof},
+			},
+		},
+		{
+			Client:   &fbapi.Client{AppSecret: "secret"},
+			Query:    "fields=id",
+			Expected: url.Values{"fields": []string{"id"}},
+		},
+		{
+			Client: &fbapi.Client{AppSecret: "secret"},
+			Query:  "access_token=token&appsecret_proof=given",
+			Expected: url.Values{
+				"access_token":    []string{"token"},
+				"appsecret_proof": []string{"given"},
+			},
+		},
+		{
+			Client: &fbapi.Client{AppSecret: "secret", AccessToken: "token"},
+			Expected: url.Values{
+				"access_token":    []string{"token"},
+				"appsecret_proof": []string{tokenSecretProof},
+			},
+		},
+		{
+			Client: &fbapi.Client{AccessToken: "token"},
+			Query:  "access_token=explicit",
+			Expected: url.Values{
+				"access_token": []string{"explicit"},
+			},
+		},
+		{
+			Client: &fbapi.Client{
+				AppSecret:   "secret",
+				AccessToken: "static",
+				TokenSource: fTokenSource(func() (string, error) { return "token", nil }),
+			},
+			Expected: url.Values{
+				"access_token":    []string{"token"},
+				"appsecret_proof": []string{tokenSecretProof},
+			},
+		},
+	}
+	for _, tc := range cases {
+		c := tc.Client
+		c.Transport = fTransport(func(r *http.Request) (*http.Response, error) {
+			ensure.DeepEqual(t, r.URL.Query(), tc.Expected, tc.Query)
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(strings.NewReader("{}")),
+			}, nil
+		})
+		_, err := c.Do(&http.Request{
+			Method: "GET",
+			URL:    &url.URL{Path: "me", RawQuery: tc.Query},
+		}, nil)
+		ensure.Nil(t, err)
+	}
+}
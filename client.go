@@ -3,12 +3,20 @@ package fbapi
 
 import (
 	"bytes"
+	"compress/gzip"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
 	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
 )
 
 var defaultBaseURL = &url.URL{
@@ -17,12 +25,69 @@ var defaultBaseURL = &url.URL{
 	Path:   "/",
 }
 
+const (
+	defaultErrorPath = "error"
+	defaultUserAgent = "fbapi-go"
+)
+
+// ErrNotModified is returned by Do for 304 Not Modified responses to
+// conditional requests, such as ones with an If-None-Match header set to the
+// ETag of a previous response, in which case the result is left untouched.
+var ErrNotModified = errors.New("fbapi: not modified")
+
+var (
+	errEmptyAccessToken      = errors.New("fbapi: access token is empty")
+	errAccessTokenWhitespace = errors.New("fbapi: access token contains whitespace")
+	errAccessTokenCharacters = errors.New("fbapi: access token contains invalid characters")
+	errNoIDs                 = errors.New("fbapi: no ids given")
+)
+
 // An Error from the API.
 type Error struct {
 	// These are provided by the Facebook API and may not always be available.
-	Message string `json:"message"`
-	Type    string `json:"type"`
-	Code    int    `json:"code"`
+	Message   string `json:"message"`
+	Type      string `json:"type"`
+	Code      int    `json:"code"`
+	Subcode   int    `json:"error_subcode"`
+	FBTraceID string `json:"fbtrace_id"`
+
+	// The delay requested by the Retry-After header of the response, if any.
+	RetryAfter time.Duration `json:"-"`
+
+	request  *http.Request
+	response *http.Response
+}
+
+// HTTPStatus returns the status code of the response the error was found in,
+// or 0 if it didn't come from a response.
+func (e *Error) HTTPStatus() int {
+	if e.response == nil {
+		return 0
+	}
+	return e.response.StatusCode
+}
+
+// ResponseHeader returns the header of the response the error was found in,
+// such as its X-FB-Debug and X-FB-Trace-ID headers which are useful to report
+// issues to Facebook, or nil if it didn't come from a response.
+func (e *Error) ResponseHeader() http.Header {
+	if e.response == nil {
+		return nil
+	}
+	return e.response.Header
+}
+
+// Request returns the request the error is the outcome of, with secrets such
+// as the access token redacted from its URL, or nil if unknown.
+func (e *Error) Request() *http.Request {
+	return e.request
+}
+
+// Response returns the response the error was found in, whose body has
+// already been read, or nil if it didn't come from a response. Its Request is
+// the one returned by Request.
+func (e *Error) Response() *http.Response {
+	return e.response
 }
 
 func (e *Error) Error() string {
@@ -31,15 +96,92 @@ func (e *Error) Error() string {
 	if e.Code != 0 {
 		fmt.Fprintf(&b, " code=%d", e.Code)
 	}
+	if e.Subcode != 0 {
+		fmt.Fprintf(&b, " subcode=%d", e.Subcode)
+	}
 	if e.Type != "" {
 		fmt.Fprintf(&b, " type=%q", e.Type)
 	}
 	if e.Message != "" {
 		fmt.Fprintf(&b, " message=%q", e.Message)
 	}
+	if e.FBTraceID != "" {
+		fmt.Fprintf(&b, " fbtrace_id=%q", e.FBTraceID)
+	}
+	if e.response != nil {
+		fmt.Fprintf(&b, " status=%d", e.response.StatusCode)
+	}
+	if e.request != nil && e.request.URL != nil {
+		fmt.Fprintf(&b, " request=%q", e.request.Method+" "+e.request.URL.String())
+	}
 	return b.String()
 }
 
+// UnmarshalJSON decodes the error, accepting codes given as strings rather than
+// numbers, as is the case for some endpoints.
+func (e *Error) UnmarshalJSON(b []byte) error {
+	type plain Error
+	v := struct {
+		*plain
+		Code    flexibleInt `json:"code"`
+		Subcode flexibleInt `json:"error_subcode"`
+	}{plain: (*plain)(e)}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	e.Code = int(v.Code)
+	e.Subcode = int(v.Subcode)
+	return nil
+}
+
+// An int decoded from a JSON number or a string containing one.
+type flexibleInt int
+
+func (i *flexibleInt) UnmarshalJSON(b []byte) error {
+	if len(b) != 0 && b[0] == '"' {
+		var s string
+		if err := json.Unmarshal(b, &s); err != nil {
+			return err
+		}
+		if s == "" {
+			*i = 0
+			return nil
+		}
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return err
+		}
+		*i = flexibleInt(n)
+		return nil
+	}
+	var n int
+	if err := json.Unmarshal(b, &n); err != nil {
+		return err
+	}
+	*i = flexibleInt(n)
+	return nil
+}
+
+// IsVersionDeprecated returns true if the error indicates the API version used
+// is no longer supported. Such errors are permanent, the requests should not
+// be retried and the version should be upgraded instead.
+func (e *Error) IsVersionDeprecated() bool {
+	return e.Code == 2635
+}
+
+// Logger is used by the Client to log warnings. It is satisfied by
+// *log.Logger.
+type Logger interface {
+	Printf(format string, v ...interface{})
+}
+
+// Stats is used by the Client to record metrics. Durations are recorded in
+// milliseconds.
+type Stats interface {
+	Inc(name string)
+	Record(name string, value float64)
+}
+
 // Client for the Facebook API.
 type Client struct {
 	// The underlying http.RoundTripper to perform the individual requests. When
@@ -48,8 +190,114 @@ type Client struct {
 
 	// The base URL to parse relative URLs off. If you pass absolute URLs to Client
 	// functions they are used as-is. When nil https://graph.facebook.com/ will
-	// be used.
+	// be used. Query parameters in the base URL are sent with relative requests,
+	// unless the request includes the same keys.
 	BaseURL *url.URL
+
+	// The API version to use, for example "v2.5". When set, the request URLs
+	// are versioned following these rules:
+	//
+	//	- Absolute URLs, such as the next URLs in paging, are never modified.
+	//	- Relative paths starting with a version like v2.4 or /v2.4 are used
+	//	  as-is.
+	//	- All other relative paths, such as me/posts or /me, are prefixed with
+	//	  the version.
+	Version string
+
+	// The path to the error object in error responses, with the keys separated
+	// by dots, for example "response.error". This is useful when a proxy wraps
+	// the errors returned by the API. When empty "error" will be used.
+	ErrorPath string
+
+	// When set, the current token is fetched for each request and sent as the
+	// access_token parameter, unless the request URL already includes one.
+	TokenSource TokenSource
+
+	// When set, this is sent as the access_token parameter for requests that
+	// don't include one and when no TokenSource is set.
+	AccessToken string
+
+	// When set, the appsecret_proof parameter is computed and sent for
+	// requests with an access_token parameter, unless it's already included.
+	// Facebook recommends this for all server side calls.
+	AppSecret string
+
+	// The User-Agent header sent with requests which don't already have one.
+	// Defaults to fbapi-go.
+	UserAgent string
+
+	// When true, Get requests fields based on the result as per FieldsOf, if
+	// it is a pointer to a struct and no fields are specified by the path or
+	// params, so only the fields which are decoded are fetched. For edge
+	// responses, structs with a data field holding a slice of structs, the
+	// fields of the items are requested. The other helpers of the Client
+	// don't use it.
+	AutoFields bool
+
+	// Used to log warnings. When nil nothing is logged.
+	Logger Logger
+
+	// Used to log every request made, including retries, once the response
+	// is read. When nil nothing is logged.
+	RequestLogger RequestLogger
+
+	// When non-zero, a warning is logged before sending requests whose fields
+	// parameter nests field expansions deeper than this. This helps catch
+	// accidental expansions resulting in enormous responses.
+	MaxFieldDepth int
+
+	// When non-zero, a warning is logged before sending requests whose fields
+	// parameter selects more than this many fields at any one level.
+	MaxFieldBreadth int
+
+	// When true, the access_token in the request URL is checked for obvious
+	// mistakes such as being empty or containing whitespace, and an error is
+	// returned without sending the request. Token formats aren't specified, so
+	// only clearly invalid tokens are rejected.
+	ValidateAccessToken bool
+
+	// Used to record metrics. When nil nothing is recorded. Each request made,
+	// including retries, is counted as request.<method> with its duration
+	// recorded as request.<method>.time, and counted as
+	// request.<method>.status.<code> when a response is received, and as
+	// request.<method>.error.<type> when it fails. The error type is the Graph
+	// API error type, unknown if it has none, response if the response could
+	// not be read or decoded, or transport if no response was received.
+	Stats Stats
+
+	// When true, the DNS, connect, TLS handshake and time to first byte timings
+	// of each request are recorded to the Stats as timing.dns, timing.connect,
+	// timing.tls and timing.first_byte. This uses net/http/httptrace, which
+	// adds some overhead.
+	TraceTiming bool
+
+	// When set, requests failing with transient errors are retried.
+	Retry *Retry
+
+	// When set, all responses are observed by the BudgetTracker, and if its
+	// Wait is true requests are delayed as needed to avoid app rate limits.
+	Budget *BudgetTracker
+
+	// Time limit for each call to Do, including retries and reading the
+	// response, after which context.DeadlineExceeded is returned. Zero means
+	// no timeout.
+	Timeout time.Duration
+
+	// Maximum number of requests in flight at once, further requests block
+	// until one completes or their context is done. Retries are counted as
+	// separate requests. Zero means no limit. It must not be changed once
+	// requests have been made.
+	MaxConcurrency int
+
+	semOnce sync.Once
+	sem     chan struct{}
+}
+
+func (c *Client) userAgent() string {
+	if c.UserAgent == "" {
+		return defaultUserAgent
+	}
+	return c.UserAgent
 }
 
 func (c *Client) transport() http.RoundTripper {
@@ -61,51 +309,368 @@ func (c *Client) transport() http.RoundTripper {
 
 // Do performs a Graph API request and unmarshal it's response. If the response
 // is an error, it will be returned as an error, else it will be unmarshalled
-// into the result.
+// into the result. If the result is an io.Writer, such as a file, the body of
+// successful responses is copied to it as is instead, which is useful for
+// binary responses such as pictures. A *json.RawMessage result, or a
+// *[]json.RawMessage for array responses, stores the JSON as is to be decoded
+// later, see also DecodeData. Responses are requested gzip compressed, unless
+// the request has an Accept-Encoding header, and transparently decompressed.
+// It is DoContext with the context of the request, which is
+// context.Background() unless one was set.
 func (c *Client) Do(req *http.Request, result interface{}) (*http.Response, error) {
+	return c.DoContext(req.Context(), req, result)
+}
+
+// DoContext performs a Graph API request like Do, using ctx for the request.
+// Cancelling the context aborts the round trip and the reading of the
+// response, in which case the context error is returned.
+func (c *Client) DoContext(ctx context.Context, req *http.Request, result interface{}) (*http.Response, error) {
+	if c.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.Timeout)
+		defer cancel()
+	}
+
+	req = req.WithContext(ctx)
 	req.Proto = "HTTP/1.1"
 	req.ProtoMajor = 1
 	req.ProtoMinor = 1
 
+	base := c.BaseURL
+	if base == nil {
+		base = defaultBaseURL
+	}
 	if req.URL == nil {
-		if c.BaseURL == nil {
-			req.URL = defaultBaseURL
-		} else {
-			req.URL = c.BaseURL
-		}
-	} else {
-		if !req.URL.IsAbs() {
-			if c.BaseURL == nil {
-				req.URL = defaultBaseURL.ResolveReference(req.URL)
-			} else {
-				req.URL = c.BaseURL.ResolveReference(req.URL)
+		// a copy, the request must not share the base URL
+		u := *base
+		req.URL = &u
+	} else if !req.URL.IsAbs() {
+		u := base.ResolveReference(c.versioned(req.URL))
+		if base.RawQuery != "" {
+			query := base.Query()
+			for key, values := range req.URL.Query() {
+				query[key] = values
 			}
+			u.RawQuery = query.Encode()
 		}
+		req.URL = u
 	}
 
 	if req.Host == "" {
 		req.Host = req.URL.Host
 	}
 
+	if c.TokenSource != nil || c.AccessToken != "" || c.AppSecret != "" {
+		if err := c.setAuthParams(req); err != nil {
+			closeRequestBody(req)
+			return nil, err
+		}
+	}
+
+	if c.ValidateAccessToken {
+		if err := validateAccessToken(req.URL); err != nil {
+			closeRequestBody(req)
+			return nil, err
+		}
+	}
+
 	if req.Header == nil {
 		req.Header = make(http.Header)
+	} else {
+		// a copy, the caller's header must not be modified
+		req.Header = req.Header.Clone()
+	}
+	if req.Header.Get("User-Agent") == "" {
+		req.Header.Set("User-Agent", c.userAgent())
+	}
+	if req.Header.Get("Accept-Encoding") == "" {
+		// set explicitly so the response is decompressed by gunzipResponse
+		// regardless of the transport used
+		req.Header.Set("Accept-Encoding", "gzip")
+	}
+
+	c.checkFields(req)
+
+	if c.Retry != nil && c.Retry.allows(req.Method) {
+		return c.doRetry(ctx, req, result)
+	}
+	return c.do(ctx, req, result)
+}
+
+// Perform a single attempt of a prepared request.
+func (c *Client) do(ctx context.Context, req *http.Request, result interface{}) (*http.Response, error) {
+	if c.TraceTiming && c.Stats != nil {
+		req = c.withTimingTrace(req)
+	}
+
+	if c.Budget != nil && c.Budget.Wait {
+		if err := c.Budget.wait(ctx); err != nil {
+			closeRequestBody(req)
+			return nil, err
+		}
 	}
 
+	if c.MaxConcurrency > 0 {
+		release, err := c.acquire(ctx)
+		if err != nil {
+			closeRequestBody(req)
+			return nil, err
+		}
+		defer release()
+	}
+
+	if c.RequestLogger == nil && c.Stats == nil {
+		return c.roundTrip(ctx, req, result)
+	}
+	start := time.Now()
+	res, err := c.roundTrip(ctx, req, result)
+	d := time.Since(start)
+	if c.Stats != nil {
+		c.recordRequest(req, res, err, d)
+	}
+	if c.RequestLogger != nil {
+		c.logRequest(req, res, err, d)
+	}
+	return res, err
+}
+
+// Close the body of a request that fails before reaching the transport, which
+// would otherwise close it. This releases the writer of a streamed body.
+func closeRequestBody(req *http.Request) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+}
+
+// Replace the body of a gzip encoded response by the decompressed body, as
+// the http.Transport does when it requests compression itself.
+func gunzipResponse(res *http.Response) error {
+	if !strings.EqualFold(res.Header.Get("Content-Encoding"), "gzip") {
+		return nil
+	}
+	zr, err := gzip.NewReader(res.Body)
+	if err != nil {
+		return err
+	}
+	res.Body = &gzipBody{Reader: zr, body: res.Body}
+	res.Header.Del("Content-Encoding")
+	res.Header.Del("Content-Length")
+	res.ContentLength = -1
+	res.Uncompressed = true
+	return nil
+}
+
+// The decompressed body of a response, closing the original body.
+type gzipBody struct {
+	*gzip.Reader
+	body io.ReadCloser
+}
+
+func (b *gzipBody) Close() error {
+	b.Reader.Close()
+	return b.body.Close()
+}
+
+// Wait for a slot among the MaxConcurrency requests in flight, returning a
+// function to release it. The context error is returned if it is done first.
+func (c *Client) acquire(ctx context.Context) (func(), error) {
+	c.semOnce.Do(func() {
+		c.sem = make(chan struct{}, c.MaxConcurrency)
+	})
+	select {
+	case c.sem <- struct{}{}:
+		return func() { <-c.sem }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Perform the round trip and unmarshal the response.
+func (c *Client) roundTrip(ctx context.Context, req *http.Request, result interface{}) (*http.Response, error) {
 	res, err := c.transport().RoundTrip(req)
 	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
 		return nil, err
 	}
 
-	if err := UnmarshalResponse(res, result); err != nil {
+	if res.Request == nil {
+		res.Request = req
+	}
+
+	if err := gunzipResponse(res); err != nil {
+		res.Body.Close()
+		return res, err
+	}
+
+	if c.Budget != nil {
+		c.Budget.Observe(res)
+	}
+
+	if err := unmarshalResponse(res, result, c.errorPath()); err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return res, ctxErr
+		}
+		if apiErr, ok := err.(*Error); ok {
+			apiErr.RetryAfter, _ = RetryAfter(res)
+		}
 		return res, err
 	}
 	return res, nil
 }
 
+// Get performs a GET request for path and unmarshals the response into result
+// like Do. The path may be relative or absolute and include a query, the
+// params are merged into it, replacing existing values for the same keys.
+func (c *Client) Get(ctx context.Context, path string, result interface{}, params ...Param) (*http.Response, error) {
+	return c.doQuery(ctx, "GET", path, result, params, c.AutoFields)
+}
+
+// Perform a GET request like Get, without AutoFields. Used by the helpers
+// whose results aren't the objects requested, such as edge envelopes or token
+// debug responses.
+func (c *Client) get(ctx context.Context, path string, result interface{}, params ...Param) (*http.Response, error) {
+	return c.doQuery(ctx, "GET", path, result, params, false)
+}
+
+// Delete performs a DELETE request for path like Get.
+func (c *Client) Delete(ctx context.Context, path string, result interface{}, params ...Param) (*http.Response, error) {
+	return c.doQuery(ctx, "DELETE", path, result, params, false)
+}
+
+// Post performs a POST request for path and unmarshals the response into
+// result like Do. The params are sent in the body as a form.
+func (c *Client) Post(ctx context.Context, path string, result interface{}, params ...Param) (*http.Response, error) {
+	v, err := ParamValues(params...)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest("POST", path, strings.NewReader(v.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return c.DoContext(ctx, req, result)
+}
+
+// PostJSON performs a POST request for path with the JSON encoding of body as
+// the request body, and unmarshals the response into result like Do. The
+// access token is sent in the query as for other requests. The body is
+// buffered so the request can be retried.
+func (c *Client) PostJSON(ctx context.Context, path string, body interface{}, result interface{}) (*http.Response, error) {
+	j, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest("POST", path, bytes.NewReader(j))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return c.DoContext(ctx, req, result)
+}
+
+// Perform a request with the params merged into the query of path, and the
+// fields derived from the result if autoFields is true.
+func (c *Client) doQuery(ctx context.Context, method, path string, result interface{}, params []Param, autoFields bool) (*http.Response, error) {
+	u, err := url.Parse(path)
+	if err != nil {
+		return nil, err
+	}
+	var fields Param
+	if autoFields {
+		fields = autoFieldsOf(result)
+	}
+	if len(params) != 0 || fields != nil {
+		v, err := ParamValues(params...)
+		if err != nil {
+			return nil, err
+		}
+		query := u.Query()
+		for key, values := range v {
+			query[key] = values
+		}
+		if fields != nil && query.Get("fields") == "" {
+			if err := fields.Set(query); err != nil {
+				return nil, err
+			}
+		}
+		u.RawQuery = query.Encode()
+	}
+	req := &http.Request{
+		Method: method,
+		URL:    u,
+		Header: make(http.Header),
+	}
+	return c.DoContext(ctx, req, result)
+}
+
+// GetByIDs fetches the objects with the given ids in one request. The response
+// is an object keyed by id, so the result is typically a map such as
+// map[string]User. The params, such as ParamFields, apply to all the objects.
+func (c *Client) GetByIDs(ctx context.Context, ids []string, result interface{}, params ...Param) error {
+	if len(ids) == 0 {
+		return errNoIDs
+	}
+	params = append(params[:len(params):len(params)], ParamIDs(ids...))
+	_, err := c.Get(ctx, "/", result, params...)
+	return err
+}
+
+// Check the access_token in the URL, if any, for obvious mistakes.
+func validateAccessToken(u *url.URL) error {
+	tokens, ok := u.Query()["access_token"]
+	if !ok {
+		return nil
+	}
+	for _, token := range tokens {
+		if token == "" {
+			return errEmptyAccessToken
+		}
+		for _, r := range token {
+			if unicode.IsSpace(r) {
+				return errAccessTokenWhitespace
+			}
+			if r > unicode.MaxASCII || !unicode.IsPrint(r) {
+				return errAccessTokenCharacters
+			}
+		}
+	}
+	return nil
+}
+
+func (c *Client) errorPath() string {
+	if c.ErrorPath == "" {
+		return defaultErrorPath
+	}
+	return c.ErrorPath
+}
+
 // UnmarshalResponse will unmarshal a http.Response from a Facebook API request
 // into result, possibly returning an error if the process fails or if the API
-// returned an error.
+// returned an error, including in a successful response with an error object.
+// A 304 Not Modified response results in ErrNotModified.
 func UnmarshalResponse(res *http.Response, result interface{}) error {
+	return unmarshalResponse(res, result, defaultErrorPath)
+}
+
+func unmarshalResponse(res *http.Response, result interface{}, errorPath string) error {
+	err := decodeResponse(res, result, errorPath)
+	if apiErr, ok := err.(*Error); ok {
+		// The response is copied to hold the redacted request rather than
+		// the one made, which may include secrets.
+		redactedRes := *res
+		if res.Request != nil {
+			redactedRes.Request = redactRequest(res.Request)
+		}
+		apiErr.request = redactedRes.Request
+		apiErr.response = &redactedRes
+	}
+	return err
+}
+
+func decodeResponse(res *http.Response, result interface{}, errorPath string) error {
 	defer res.Body.Close()
 
 	if res.StatusCode > 399 || res.StatusCode < 200 {
@@ -114,23 +679,92 @@ func UnmarshalResponse(res *http.Response, result interface{}) error {
 			return err
 		}
 
-		var apiErrorResponse struct {
-			Error Error `json:"error"`
-		}
-		if err := json.Unmarshal(body, &apiErrorResponse); err != nil {
+		return unmarshalError(body, errorPath)
+	}
+
+	if res.StatusCode == http.StatusNotModified {
+		return ErrNotModified
+	}
+
+	switch r := result.(type) {
+	case *Success:
+		return unmarshalSuccess(res.Body, r, errorPath)
+	case *bool:
+		var s Success
+		if err := unmarshalSuccess(res.Body, &s, errorPath); err != nil {
 			return err
 		}
-		return &apiErrorResponse.Error
+		*r = s.OK
+		return nil
+	case io.Writer:
+		_, err := io.Copy(r, res.Body)
+		return err
 	}
 
-	var err error
+	// successful responses may still contain an error, so the body is
+	// buffered to check for one before decoding it
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+
+	if apiError := findError(body, errorPath); apiError != nil {
+		return apiError
+	}
 	if result == nil {
-		_, err = io.Copy(ioutil.Discard, res.Body)
-	} else {
-		err = json.NewDecoder(res.Body).Decode(result)
+		return nil
 	}
-	if err != nil {
+	if err := json.NewDecoder(bytes.NewReader(body)).Decode(result); err != nil {
 		return err
 	}
 	return nil
 }
+
+// Returns the value at the given path in body, or nil if it's missing.
+func lookupPath(body []byte, path string) ([]byte, error) {
+	for _, key := range strings.Split(path, ".") {
+		var parent map[string]json.RawMessage
+		if err := json.Unmarshal(body, &parent); err != nil {
+			return nil, err
+		}
+		if body = parent[key]; body == nil {
+			return nil, nil
+		}
+	}
+	return body, nil
+}
+
+// Unmarshal the Error found at the given path in body. A missing error object
+// results in an empty Error.
+func unmarshalError(body []byte, errorPath string) error {
+	raw, err := lookupPath(body, errorPath)
+	if err != nil {
+		return err
+	}
+	var apiError Error
+	if raw != nil {
+		if err := json.Unmarshal(raw, &apiError); err != nil {
+			return err
+		}
+	}
+	return &apiError
+}
+
+// Returns the Error found at the given path in a successful response body, or
+// nil if there isn't one. As the response may legitimately have a field with
+// the name of the error, it is only considered to be one if it looks like an
+// API error, with a code or message.
+func findError(body []byte, errorPath string) *Error {
+	raw, err := lookupPath(body, errorPath)
+	if err != nil || raw == nil || string(raw) == "null" {
+		return nil
+	}
+	var apiError Error
+	if err := json.Unmarshal(raw, &apiError); err != nil {
+		return nil
+	}
+	if apiError.Code == 0 && apiError.Message == "" {
+		return nil
+	}
+	return &apiError
+}
@@ -2,14 +2,21 @@ package fbapi_test
 
 import (
 	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"regexp"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/facebookgo/ensure"
 	"github.com/facebookgo/fbapi"
@@ -22,6 +29,44 @@ func (f fTransport) RoundTrip(r *http.Request) (*http.Response, error) {
 	return f(r)
 }
 
+// Returns a client responding to all requests with the given body. The path
+// of the requests is checked unless empty, as are the values of the query
+// params in query, where nil values check the param is absent.
+func bodyClient(t *testing.T, path string, query url.Values, body string) *fbapi.Client {
+	return &fbapi.Client{
+		Transport: fTransport(func(r *http.Request) (*http.Response, error) {
+			if path != "" {
+				ensure.DeepEqual(t, r.URL.Path, path)
+			}
+			actual := r.URL.Query()
+			for key, values := range query {
+				ensure.DeepEqual(t, actual[key], values, key)
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(strings.NewReader(body)),
+			}, nil
+		}),
+	}
+}
+
+// Returns a copy of the error without the response details, which are only
+// available through methods, so it can be compared to an expected *fbapi.Error.
+func withoutResponse(err error) error {
+	apiErr, ok := err.(*fbapi.Error)
+	if !ok {
+		return err
+	}
+	return &fbapi.Error{
+		Message:    apiErr.Message,
+		Type:       apiErr.Type,
+		Code:       apiErr.Code,
+		Subcode:    apiErr.Subcode,
+		FBTraceID:  apiErr.FBTraceID,
+		RetryAfter: apiErr.RetryAfter,
+	}
+}
+
 func TestErrorString(t *testing.T) {
 	e := fbapi.Error{
 		Message: "m",
@@ -31,6 +76,97 @@ func TestErrorString(t *testing.T) {
 	ensure.DeepEqual(t, e.Error(), `fbapi: error code=42 type="t" message="m"`)
 }
 
+func TestErrorStringSubcodeAndTrace(t *testing.T) {
+	e := fbapi.Error{
+		Message:   "m",
+		Type:      "OAuthException",
+		Code:      190,
+		Subcode:   463,
+		FBTraceID: "AbC123",
+	}
+	ensure.DeepEqual(t, e.Error(),
+		`fbapi: error code=190 subcode=463 type="OAuthException" message="m" fbtrace_id="AbC123"`)
+}
+
+func TestErrorResponseSubcodeAndTrace(t *testing.T) {
+	t.Parallel()
+	c := &fbapi.Client{
+		Transport: fTransport(func(r *http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: http.StatusBadRequest,
+				Body: ioutil.NopCloser(strings.NewReader(`{"error":{
+					"message":"Error validating access token: Session has expired",
+					"type":"OAuthException",
+					"code":190,
+					"error_subcode":463,
+					"fbtrace_id":"AbC123"
+				}}`)),
+			}, nil
+		}),
+	}
+	_, err := c.Do(&http.Request{Method: "GET"}, nil)
+	ensure.DeepEqual(t, withoutResponse(err), &fbapi.Error{
+		Message:   "Error validating access token: Session has expired",
+		Type:      "OAuthException",
+		Code:      190,
+		Subcode:   463,
+		FBTraceID: "AbC123",
+	})
+}
+
+func TestErrorCodesAsStrings(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		JSON     string
+		Expected fbapi.Error
+	}{
+		{
+			JSON:     `{"code":190,"error_subcode":463,"type":"OAuthException"}`,
+			Expected: fbapi.Error{Code: 190, Subcode: 463, Type: "OAuthException"},
+		},
+		{
+			JSON:     `{"code":"190","error_subcode":"463","type":"OAuthException"}`,
+			Expected: fbapi.Error{Code: 190, Subcode: 463, Type: "OAuthException"},
+		},
+		{
+			JSON:     `{"code":"","message":"m"}`,
+			Expected: fbapi.Error{Message: "m"},
+		},
+		{
+			JSON:     `{"code":null}`,
+			Expected: fbapi.Error{},
+		},
+	}
+	for _, tc := range cases {
+		var actual fbapi.Error
+		ensure.Nil(t, json.Unmarshal([]byte(tc.JSON), &actual), tc.JSON)
+		ensure.DeepEqual(t, actual, tc.Expected)
+	}
+}
+
+func TestErrorCodesInvalid(t *testing.T) {
+	t.Parallel()
+	for _, j := range []string{`{"code":"abc"}`, `{"error_subcode":true}`, `{"code":1.5}`} {
+		var actual fbapi.Error
+		ensure.NotNil(t, json.Unmarshal([]byte(j), &actual), j)
+	}
+}
+
+func TestErrorResponseStringCode(t *testing.T) {
+	t.Parallel()
+	c := &fbapi.Client{
+		Transport: fTransport(func(r *http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: http.StatusBadRequest,
+				Body: ioutil.NopCloser(strings.NewReader(
+					`{"error":{"message":"expired","code":"190","error_subcode":"463"}}`)),
+			}, nil
+		}),
+	}
+	_, err := c.Do(&http.Request{Method: "GET"}, nil)
+	ensure.DeepEqual(t, withoutResponse(err), &fbapi.Error{Message: "expired", Code: 190, Subcode: 463})
+}
+
 func TestCustomBaseURL(t *testing.T) {
 	t.Parallel()
 	baseURL := &url.URL{
@@ -53,6 +189,93 @@ func TestCustomBaseURL(t *testing.T) {
 	ensure.True(t, err == givenErr, err)
 }
 
+func TestBaseURLNotShared(t *testing.T) {
+	t.Parallel()
+	baseURL := &url.URL{
+		Scheme:   "https",
+		Host:     "example.com",
+		Path:     "/",
+		RawQuery: "a=b",
+	}
+	c := &fbapi.Client{
+		BaseURL: baseURL,
+		Transport: fTransport(func(r *http.Request) (*http.Response, error) {
+			ensure.DeepEqual(t, r.URL.String(), "https://example.com/?a=b")
+			r.URL.Path = "/mutated"
+			r.URL.RawQuery = "mutated=1"
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(strings.NewReader(`{}`)),
+			}, nil
+		}),
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := c.Do(&http.Request{Method: "GET"}, nil)
+			ensure.Nil(t, err)
+		}()
+	}
+	wg.Wait()
+	ensure.DeepEqual(t, baseURL, &url.URL{
+		Scheme:   "https",
+		Host:     "example.com",
+		Path:     "/",
+		RawQuery: "a=b",
+	})
+}
+
+func TestBaseURLQuery(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		BaseURL  string
+		URL      *url.URL
+		Expected string
+	}{
+		{
+			BaseURL:  "https://example.com/?a=1&b=2",
+			URL:      &url.URL{Path: "me"},
+			Expected: "https://example.com/me?a=1&b=2",
+		},
+		{
+			BaseURL:  "https://example.com/",
+			URL:      &url.URL{Path: "me", RawQuery: "c=3"},
+			Expected: "https://example.com/me?c=3",
+		},
+		{
+			BaseURL:  "https://example.com/?a=1&b=2",
+			URL:      &url.URL{Path: "me", RawQuery: "b=3&c=4"},
+			Expected: "https://example.com/me?a=1&b=3&c=4",
+		},
+		{
+			BaseURL:  "https://example.com/?a=1",
+			Expected: "https://example.com/?a=1",
+		},
+		{
+			BaseURL:  "https://example.com/?a=1",
+			URL:      &url.URL{Scheme: "https", Host: "example.org", Path: "/me", RawQuery: "b=2"},
+			Expected: "https://example.org/me?b=2",
+		},
+	}
+	for _, tc := range cases {
+		baseURL, err := url.Parse(tc.BaseURL)
+		ensure.Nil(t, err)
+		givenErr := errors.New("")
+		c := &fbapi.Client{
+			BaseURL: baseURL,
+			Transport: fTransport(func(r *http.Request) (*http.Response, error) {
+				ensure.DeepEqual(t, r.URL.String(), tc.Expected)
+				return nil, givenErr
+			}),
+		}
+		_, err = c.Do(&http.Request{Method: "GET", URL: tc.URL}, nil)
+		ensure.True(t, err == givenErr, err)
+		ensure.DeepEqual(t, baseURL.String(), tc.BaseURL)
+	}
+}
+
 func TestDefaultBaseURL(t *testing.T) {
 	t.Parallel()
 	givenErr := errors.New("")
@@ -104,7 +327,7 @@ func TestErrorResponse(t *testing.T) {
 	}
 	var actual map[string]string
 	_, err := c.Do(&http.Request{Method: "GET"}, &actual)
-	ensure.DeepEqual(t, err, givenErr)
+	ensure.DeepEqual(t, withoutResponse(err), givenErr)
 }
 
 func TestServerAbort(t *testing.T) {
@@ -159,3 +382,933 @@ func TestTransportError(t *testing.T) {
 	_, err := c.Do(&http.Request{Method: "GET"}, nil)
 	ensure.True(t, err == givenErr)
 }
+
+func TestCustomErrorPath(t *testing.T) {
+	t.Parallel()
+	givenErr := &fbapi.Error{
+		Message: "message42",
+		Type:    "type42",
+		Code:    42,
+	}
+	given := map[string]interface{}{
+		"status": "failed",
+		"response": map[string]interface{}{
+			"error": givenErr,
+		},
+	}
+	c := &fbapi.Client{
+		ErrorPath: "response.error",
+		Transport: fTransport(func(r *http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: http.StatusBadRequest,
+				Body:       ioutil.NopCloser(jsonpipe.Encode(given)),
+			}, nil
+		}),
+	}
+	_, err := c.Do(&http.Request{Method: "GET"}, nil)
+	ensure.DeepEqual(t, withoutResponse(err), givenErr)
+}
+
+type fTokenSource func() (string, error)
+
+func (f fTokenSource) Token() (string, error) {
+	return f()
+}
+
+func TestTokenSource(t *testing.T) {
+	t.Parallel()
+	var calls int
+	c := &fbapi.Client{
+		TokenSource: fTokenSource(func() (string, error) {
+			calls++
+			return fmt.Sprintf("token%d", calls), nil
+		}),
+		Transport: fTransport(func(r *http.Request) (*http.Response, error) {
+			ensure.DeepEqual(t, r.URL.Query().Get("access_token"), fmt.Sprintf("token%d", calls))
+			ensure.DeepEqual(t, r.URL.Query().Get("fields"), "id")
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(strings.NewReader("{}")),
+			}, nil
+		}),
+	}
+	for i := 0; i < 3; i++ {
+		_, err := c.Do(&http.Request{
+			Method: "GET",
+			URL:    &url.URL{Path: "me", RawQuery: "fields=id"},
+		}, nil)
+		ensure.Nil(t, err)
+	}
+	ensure.DeepEqual(t, calls, 3)
+}
+
+func TestTokenSourceExplicitToken(t *testing.T) {
+	t.Parallel()
+	c := &fbapi.Client{
+		TokenSource: fTokenSource(func() (string, error) {
+			panic("not reached")
+		}),
+		Transport: fTransport(func(r *http.Request) (*http.Response, error) {
+			ensure.DeepEqual(t, r.URL.Query().Get("access_token"), "explicit")
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(strings.NewReader("{}")),
+			}, nil
+		}),
+	}
+	_, err := c.Do(&http.Request{
+		Method: "GET",
+		URL:    &url.URL{Path: "me", RawQuery: "access_token=explicit"},
+	}, nil)
+	ensure.Nil(t, err)
+}
+
+func TestTokenSourceError(t *testing.T) {
+	t.Parallel()
+	givenErr := errors.New("")
+	c := &fbapi.Client{
+		TokenSource: fTokenSource(func() (string, error) {
+			return "", givenErr
+		}),
+	}
+	_, err := c.Do(&http.Request{Method: "GET"}, nil)
+	ensure.True(t, err == givenErr, err)
+}
+
+type closeRecorder struct {
+	io.Reader
+	closed bool
+}
+
+func (c *closeRecorder) Close() error {
+	c.closed = true
+	return nil
+}
+
+func TestDoClosesBodyOnEarlyError(t *testing.T) {
+	t.Parallel()
+	clients := []*fbapi.Client{
+		{
+			AccessToken:         "bad token",
+			ValidateAccessToken: true,
+		},
+		{
+			TokenSource: fTokenSource(func() (string, error) {
+				return "", errors.New("no token")
+			}),
+		},
+	}
+	for _, c := range clients {
+		c.Transport = fTransport(func(r *http.Request) (*http.Response, error) {
+			panic("not reached")
+		})
+		body := &closeRecorder{Reader: strings.NewReader("a=b")}
+		_, err := c.Do(&http.Request{Method: "POST", Body: body}, nil)
+		ensure.NotNil(t, err)
+		ensure.True(t, body.closed)
+	}
+}
+
+func TestDoClosesBodyWhenNotAcquired(t *testing.T) {
+	t.Parallel()
+	started := make(chan struct{})
+	unblock := make(chan struct{})
+	c := &fbapi.Client{
+		MaxConcurrency: 1,
+		Transport: fTransport(func(r *http.Request) (*http.Response, error) {
+			close(started)
+			<-unblock
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(strings.NewReader(`{}`)),
+			}, nil
+		}),
+	}
+	done := make(chan error)
+	go func() {
+		_, err := c.Do(&http.Request{Method: "GET"}, nil)
+		done <- err
+	}()
+	<-started
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	body := &closeRecorder{Reader: strings.NewReader("a=b")}
+	_, err := c.DoContext(ctx, &http.Request{Method: "POST", Body: body}, nil)
+	ensure.DeepEqual(t, err, context.Canceled)
+	ensure.True(t, body.closed)
+
+	close(unblock)
+	ensure.Nil(t, <-done)
+}
+
+func TestVersionDeprecatedError(t *testing.T) {
+	t.Parallel()
+	var calls int
+	given := map[string]interface{}{
+		"error": map[string]interface{}{
+			"message": "(#2635) You are calling a deprecated version of the Ads API.",
+			"type":    "OAuthException",
+			"code":    2635,
+		},
+	}
+	c := &fbapi.Client{
+		Transport: fTransport(func(r *http.Request) (*http.Response, error) {
+			calls++
+			return &http.Response{
+				StatusCode: http.StatusBadRequest,
+				Body:       ioutil.NopCloser(jsonpipe.Encode(given)),
+			}, nil
+		}),
+	}
+	_, err := c.Do(&http.Request{Method: "GET"}, nil)
+	ensure.True(t, err.(*fbapi.Error).IsVersionDeprecated(), err)
+	ensure.DeepEqual(t, calls, 1)
+	ensure.False(t, (&fbapi.Error{Code: 100}).IsVersionDeprecated())
+}
+
+func TestValidateAccessToken(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		Query string
+		Error string
+	}{
+		{Query: "access_token=abc%20def", Error: "fbapi: access token contains whitespace"},
+		{Query: "access_token=abc%0A", Error: "fbapi: access token contains whitespace"},
+		{Query: "access_token=", Error: "fbapi: access token is empty"},
+		{Query: "access_token=%C3%A9", Error: "fbapi: access token contains invalid characters"},
+		{Query: "access_token=123%7Cabc"},
+		{Query: "fields=id"},
+	}
+	for _, tc := range cases {
+		var sent bool
+		c := &fbapi.Client{
+			ValidateAccessToken: true,
+			Transport: fTransport(func(r *http.Request) (*http.Response, error) {
+				sent = true
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       ioutil.NopCloser(strings.NewReader("{}")),
+				}, nil
+			}),
+		}
+		_, err := c.Do(&http.Request{
+			Method: "GET",
+			URL:    &url.URL{Path: "me", RawQuery: tc.Query},
+		}, nil)
+		if tc.Error == "" {
+			ensure.Nil(t, err, tc.Query)
+			ensure.True(t, sent, tc.Query)
+		} else {
+			ensure.Err(t, err, regexp.MustCompile("^"+tc.Error+"$"))
+			ensure.False(t, sent, tc.Query)
+		}
+	}
+}
+
+func TestDoContextCancelledRoundTrip(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	c := &fbapi.Client{
+		Transport: fTransport(func(r *http.Request) (*http.Response, error) {
+			cancel()
+			<-r.Context().Done()
+			return nil, errors.New("opaque transport error")
+		}),
+	}
+	_, err := c.DoContext(ctx, &http.Request{Method: "GET"}, nil)
+	ensure.True(t, err == context.Canceled, err)
+}
+
+type ctxBody struct {
+	ctx    context.Context
+	closed bool
+}
+
+func (b *ctxBody) Read(p []byte) (int, error) {
+	<-b.ctx.Done()
+	return 0, errors.New("opaque read error")
+}
+
+func (b *ctxBody) Close() error {
+	b.closed = true
+	return nil
+}
+
+func TestDoContextCancelledRead(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	body := &ctxBody{ctx: ctx}
+	c := &fbapi.Client{
+		Transport: fTransport(func(r *http.Request) (*http.Response, error) {
+			ensure.True(t, r.Context() == ctx)
+			time.AfterFunc(time.Millisecond, cancel)
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       body,
+			}, nil
+		}),
+	}
+	var actual map[string]string
+	_, err := c.DoContext(ctx, &http.Request{Method: "GET"}, &actual)
+	ensure.True(t, err == context.Canceled, err)
+	ensure.True(t, body.closed)
+}
+
+func TestDoUsesRequestContext(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	c := &fbapi.Client{
+		Transport: fTransport(func(r *http.Request) (*http.Response, error) {
+			ensure.True(t, r.Context() == ctx)
+			return nil, errors.New("")
+		}),
+	}
+	req, err := http.NewRequest("GET", "me", nil)
+	ensure.Nil(t, err)
+	_, err = c.Do(req.WithContext(ctx), nil)
+	ensure.True(t, err == context.Canceled, err)
+}
+
+func TestNilResultErrorInSuccessfulResponse(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		Body  string
+		Error error
+	}{
+		{
+			Body:  `{"error":{"code":100,"message":"m"}}`,
+			Error: &fbapi.Error{Code: 100, Message: "m"},
+		},
+		{Body: `{"success":true}`},
+		{Body: `{"error":null}`},
+		{Body: `true`},
+		{Body: ``},
+	}
+	for _, tc := range cases {
+		c := &fbapi.Client{
+			Transport: fTransport(func(r *http.Request) (*http.Response, error) {
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       ioutil.NopCloser(strings.NewReader(tc.Body)),
+				}, nil
+			}),
+		}
+		_, err := c.Do(&http.Request{Method: "GET"}, nil)
+		ensure.DeepEqual(t, withoutResponse(err), tc.Error, tc.Body)
+	}
+}
+
+func TestResultErrorInSuccessfulResponse(t *testing.T) {
+	t.Parallel()
+	type status struct {
+		ID    string          `json:"id"`
+		Error json.RawMessage `json:"error"`
+	}
+	cases := []struct {
+		Body     string
+		Error    error
+		Expected status
+	}{
+		{
+			Body:  `{"error":{"code":100,"message":"m"}}`,
+			Error: &fbapi.Error{Code: 100, Message: "m"},
+		},
+		{
+			Body:     `{"id":"1","error":"quota exceeded"}`,
+			Expected: status{ID: "1", Error: json.RawMessage(`"quota exceeded"`)},
+		},
+		{
+			Body:     `{"id":"1","error":{"reason":"none"}}`,
+			Expected: status{ID: "1", Error: json.RawMessage(`{"reason":"none"}`)},
+		},
+		{
+			Body:     `{"id":"1","error":null}`,
+			Expected: status{ID: "1", Error: json.RawMessage(`null`)},
+		},
+		{
+			Body:     `{"id":"1"}`,
+			Expected: status{ID: "1"},
+		},
+	}
+	for _, tc := range cases {
+		c := &fbapi.Client{
+			Transport: fTransport(func(r *http.Request) (*http.Response, error) {
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       ioutil.NopCloser(strings.NewReader(tc.Body)),
+				}, nil
+			}),
+		}
+		var actual status
+		_, err := c.Do(&http.Request{Method: "GET"}, &actual)
+		ensure.DeepEqual(t, withoutResponse(err), tc.Error, tc.Body)
+		ensure.DeepEqual(t, actual, tc.Expected, tc.Body)
+	}
+}
+
+func TestGet(t *testing.T) {
+	t.Parallel()
+	c := &fbapi.Client{
+		Transport: fTransport(func(r *http.Request) (*http.Response, error) {
+			ensure.DeepEqual(t, r.Method, "GET")
+			ensure.DeepEqual(t, r.URL.String(), "https://graph.facebook.com/me/feed?fields=id%2Cmessage&limit=10")
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(strings.NewReader(`{"data":[{"id":"1"}]}`)),
+			}, nil
+		}),
+	}
+	var feed struct {
+		Data []struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	_, err := c.Get(context.Background(), "me/feed?limit=5", &feed,
+		fbapi.ParamFields("id", "message"), fbapi.Values{"limit": {"10"}})
+	ensure.Nil(t, err)
+	ensure.DeepEqual(t, len(feed.Data), 1)
+	ensure.DeepEqual(t, feed.Data[0].ID, "1")
+}
+
+func TestGetParamError(t *testing.T) {
+	t.Parallel()
+	c := &fbapi.Client{
+		Transport: fTransport(func(r *http.Request) (*http.Response, error) {
+			panic("not reached")
+		}),
+	}
+	_, err := c.Get(context.Background(), "me", nil, paramWithError{})
+	ensure.Err(t, err, regexp.MustCompile(paramWithErrorMessage))
+}
+
+func TestPost(t *testing.T) {
+	t.Parallel()
+	c := &fbapi.Client{
+		AccessToken: "token",
+		Transport: fTransport(func(r *http.Request) (*http.Response, error) {
+			ensure.DeepEqual(t, r.Method, "POST")
+			ensure.DeepEqual(t, r.URL.String(), "https://graph.facebook.com/me/feed?access_token=token")
+			ensure.DeepEqual(t, r.Header.Get("Content-Type"), "application/x-www-form-urlencoded")
+			ensure.Nil(t, r.ParseForm())
+			ensure.DeepEqual(t, r.PostForm, url.Values{
+				"message": []string{"hello world"},
+				"link":    []string{"https://example.com/"},
+			})
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(strings.NewReader(`{"id":"1_2"}`)),
+			}, nil
+		}),
+	}
+	var post struct {
+		ID string `json:"id"`
+	}
+	_, err := c.Post(context.Background(), "me/feed", &post,
+		fbapi.ParamString("message", "hello world"), fbapi.ParamString("link", "https://example.com/"))
+	ensure.Nil(t, err)
+	ensure.DeepEqual(t, post.ID, "1_2")
+}
+
+func TestPostParamError(t *testing.T) {
+	t.Parallel()
+	c := &fbapi.Client{
+		Transport: fTransport(func(r *http.Request) (*http.Response, error) {
+			panic("not reached")
+		}),
+	}
+	_, err := c.Post(context.Background(), "me/feed", nil, paramWithError{})
+	ensure.Err(t, err, regexp.MustCompile(paramWithErrorMessage))
+}
+
+func TestPostJSON(t *testing.T) {
+	t.Parallel()
+	var bodies []string
+	transport, calls := sequenceTransport(t,
+		fakeResponse{Code: http.StatusServiceUnavailable, Body: `{"error":{"code":2}}`},
+		fakeResponse{Code: http.StatusOK, Body: `{"id":"42"}`},
+	)
+	c := &fbapi.Client{
+		AccessToken: "token",
+		Retry:       &fbapi.Retry{BaseDelay: time.Millisecond, AllMethods: true},
+		Transport: fTransport(func(r *http.Request) (*http.Response, error) {
+			ensure.DeepEqual(t, r.Method, "POST")
+			ensure.DeepEqual(t, r.URL.String(), "https://graph.facebook.com/act_1/campaigns?access_token=token")
+			ensure.DeepEqual(t, r.Header.Get("Content-Type"), "application/json")
+			b, err := ioutil.ReadAll(r.Body)
+			ensure.Nil(t, err)
+			bodies = append(bodies, string(b))
+			return transport.RoundTrip(r)
+		}),
+	}
+	body := map[string]interface{}{
+		"name":                  "Campaign",
+		"special_ad_categories": []string{},
+	}
+	var created struct {
+		ID string `json:"id"`
+	}
+	_, err := c.PostJSON(context.Background(), "act_1/campaigns", body, &created)
+	ensure.Nil(t, err)
+	ensure.DeepEqual(t, created.ID, "42")
+	ensure.DeepEqual(t, *calls, 2)
+	const expected = `{"name":"Campaign","special_ad_categories":[]}`
+	ensure.DeepEqual(t, bodies, []string{expected, expected})
+}
+
+func TestPostJSONMarshalError(t *testing.T) {
+	t.Parallel()
+	c := &fbapi.Client{
+		Transport: fTransport(func(r *http.Request) (*http.Response, error) {
+			panic("not reached")
+		}),
+	}
+	_, err := c.PostJSON(context.Background(), "me/feed", make(chan int), nil)
+	ensure.NotNil(t, err)
+}
+
+func TestDelete(t *testing.T) {
+	t.Parallel()
+	c := &fbapi.Client{
+		Transport: fTransport(func(r *http.Request) (*http.Response, error) {
+			ensure.DeepEqual(t, r.Method, "DELETE")
+			ensure.DeepEqual(t, r.URL.String(), "https://graph.facebook.com/1_2?access_token=page")
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(strings.NewReader(`{"success":true}`)),
+			}, nil
+		}),
+	}
+	var ok bool
+	_, err := c.Delete(context.Background(), "1_2", &ok, fbapi.ParamAccessToken("page"))
+	ensure.Nil(t, err)
+	ensure.True(t, ok)
+}
+
+func TestGetByIDs(t *testing.T) {
+	t.Parallel()
+	c := &fbapi.Client{
+		Version: "v2.5",
+		Transport: fTransport(func(r *http.Request) (*http.Response, error) {
+			ensure.DeepEqual(t, r.URL.String(), "https://graph.facebook.com/v2.5/?fields=id%2Cname&ids=1%2C2")
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body: ioutil.NopCloser(strings.NewReader(
+					`{"1":{"id":"1","name":"a"},"2":{"id":"2","name":"b"}}`)),
+			}, nil
+		}),
+	}
+	type user struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	}
+	var users map[string]user
+	err := c.GetByIDs(context.Background(), []string{"1", "2"}, &users, fbapi.ParamFields("id", "name"))
+	ensure.Nil(t, err)
+	ensure.DeepEqual(t, users, map[string]user{
+		"1": {ID: "1", Name: "a"},
+		"2": {ID: "2", Name: "b"},
+	})
+}
+
+func TestGetByIDsEmpty(t *testing.T) {
+	t.Parallel()
+	c := &fbapi.Client{
+		Transport: fTransport(func(r *http.Request) (*http.Response, error) {
+			panic("not reached")
+		}),
+	}
+	err := c.GetByIDs(context.Background(), nil, nil)
+	ensure.Err(t, err, regexp.MustCompile("no ids given"))
+}
+
+func TestWriterResult(t *testing.T) {
+	t.Parallel()
+	image := "\x89PNG\r\n\x1a\nnot json"
+	c := &fbapi.Client{
+		Transport: fTransport(func(r *http.Request) (*http.Response, error) {
+			ensure.DeepEqual(t, r.URL.String(), "https://graph.facebook.com/42/picture?redirect=true")
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Header:     http.Header{"Content-Type": []string{"image/png"}},
+				Body:       ioutil.NopCloser(strings.NewReader(image)),
+			}, nil
+		}),
+	}
+	var buf bytes.Buffer
+	_, err := c.Get(context.Background(), "42/picture", &buf, fbapi.ParamString("redirect", "true"))
+	ensure.Nil(t, err)
+	ensure.DeepEqual(t, buf.String(), image)
+}
+
+func TestWriterResultError(t *testing.T) {
+	t.Parallel()
+	c := &fbapi.Client{
+		Transport: fTransport(func(r *http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: http.StatusNotFound,
+				Body:       ioutil.NopCloser(strings.NewReader(`{"error":{"code":803}}`)),
+			}, nil
+		}),
+	}
+	var buf bytes.Buffer
+	_, err := c.Get(context.Background(), "42/picture", &buf)
+	ensure.DeepEqual(t, withoutResponse(err), &fbapi.Error{Code: 803})
+	ensure.DeepEqual(t, buf.Len(), 0)
+}
+
+func TestMaxConcurrency(t *testing.T) {
+	t.Parallel()
+	var (
+		mu          sync.Mutex
+		inFlight    int
+		maxInFlight int
+	)
+	c := &fbapi.Client{
+		MaxConcurrency: 2,
+		Transport: fTransport(func(r *http.Request) (*http.Response, error) {
+			mu.Lock()
+			inFlight++
+			if inFlight > maxInFlight {
+				maxInFlight = inFlight
+			}
+			mu.Unlock()
+			time.Sleep(5 * time.Millisecond)
+			mu.Lock()
+			inFlight--
+			mu.Unlock()
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(strings.NewReader("{}")),
+			}, nil
+		}),
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := c.Do(&http.Request{Method: "GET"}, nil)
+			ensure.Nil(t, err)
+		}()
+	}
+	wg.Wait()
+	ensure.DeepEqual(t, maxInFlight, 2)
+}
+
+func TestMaxConcurrencyContextDone(t *testing.T) {
+	t.Parallel()
+	started := make(chan struct{})
+	unblock := make(chan struct{})
+	c := &fbapi.Client{
+		MaxConcurrency: 1,
+		Transport: fTransport(func(r *http.Request) (*http.Response, error) {
+			close(started)
+			<-unblock
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(strings.NewReader("{}")),
+			}, nil
+		}),
+	}
+	done := make(chan error)
+	go func() {
+		_, err := c.Do(&http.Request{Method: "GET"}, nil)
+		done <- err
+	}()
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	_, err := c.DoContext(ctx, &http.Request{Method: "GET"}, nil)
+	ensure.DeepEqual(t, err, context.DeadlineExceeded)
+
+	close(unblock)
+	ensure.Nil(t, <-done)
+}
+
+func TestUserAgent(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		UserAgent string
+		Header    http.Header
+		Expected  string
+	}{
+		{Expected: "fbapi-go"},
+		{UserAgent: "myapp/1.0", Expected: "myapp/1.0"},
+		{
+			UserAgent: "myapp/1.0",
+			Header:    http.Header{"User-Agent": []string{"caller/2.0"}},
+			Expected:  "caller/2.0",
+		},
+	}
+	for _, tc := range cases {
+		c := &fbapi.Client{
+			UserAgent: tc.UserAgent,
+			Transport: fTransport(func(r *http.Request) (*http.Response, error) {
+				ensure.DeepEqual(t, r.Header.Get("User-Agent"), tc.Expected)
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       ioutil.NopCloser(strings.NewReader("{}")),
+				}, nil
+			}),
+		}
+		_, err := c.Do(&http.Request{Method: "GET", Header: tc.Header}, nil)
+		ensure.Nil(t, err)
+	}
+}
+
+func TestUserAgentDoesNotModifyRequestHeader(t *testing.T) {
+	t.Parallel()
+	c := &fbapi.Client{
+		Transport: fTransport(func(r *http.Request) (*http.Response, error) {
+			ensure.DeepEqual(t, r.Header.Get("User-Agent"), "fbapi-go")
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(strings.NewReader("{}")),
+			}, nil
+		}),
+	}
+	header := http.Header{"Accept": []string{"application/json"}}
+	_, err := c.Do(&http.Request{Method: "GET", Header: header}, nil)
+	ensure.Nil(t, err)
+	ensure.DeepEqual(t, header, http.Header{"Accept": []string{"application/json"}})
+}
+
+func TestTimeout(t *testing.T) {
+	t.Parallel()
+	c := &fbapi.Client{
+		Timeout: time.Millisecond,
+		Transport: fTransport(func(r *http.Request) (*http.Response, error) {
+			<-r.Context().Done()
+			return nil, r.Context().Err()
+		}),
+	}
+	_, err := c.Do(&http.Request{Method: "GET"}, nil)
+	ensure.DeepEqual(t, err, context.DeadlineExceeded)
+}
+
+func TestTimeoutNotReached(t *testing.T) {
+	t.Parallel()
+	c := &fbapi.Client{
+		Timeout: time.Hour,
+		Transport: fTransport(func(r *http.Request) (*http.Response, error) {
+			deadline, ok := r.Context().Deadline()
+			ensure.True(t, ok)
+			ensure.True(t, time.Until(deadline) > time.Minute, deadline)
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(strings.NewReader(`{"id":"42"}`)),
+			}, nil
+		}),
+	}
+	var actual map[string]string
+	_, err := c.Do(&http.Request{Method: "GET"}, &actual)
+	ensure.Nil(t, err)
+	ensure.DeepEqual(t, actual, map[string]string{"id": "42"})
+}
+
+func gzipped(t *testing.T, s string) []byte {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	_, err := zw.Write([]byte(s))
+	ensure.Nil(t, err)
+	ensure.Nil(t, zw.Close())
+	return buf.Bytes()
+}
+
+func TestGzipResponse(t *testing.T) {
+	t.Parallel()
+	body := gzipped(t, `{"id":"42"}`)
+	c := &fbapi.Client{
+		Transport: fTransport(func(r *http.Request) (*http.Response, error) {
+			ensure.DeepEqual(t, r.Header.Get("Accept-Encoding"), "gzip")
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Header: http.Header{
+					"Content-Encoding": []string{"gzip"},
+					"Content-Length":   []string{fmt.Sprint(len(body))},
+				},
+				ContentLength: int64(len(body)),
+				Body:          ioutil.NopCloser(bytes.NewReader(body)),
+			}, nil
+		}),
+	}
+	var actual map[string]string
+	res, err := c.Do(&http.Request{Method: "GET"}, &actual)
+	ensure.Nil(t, err)
+	ensure.DeepEqual(t, actual, map[string]string{"id": "42"})
+	ensure.True(t, res.Uncompressed)
+	ensure.DeepEqual(t, res.ContentLength, int64(-1))
+	ensure.DeepEqual(t, res.Header.Get("Content-Encoding"), "")
+}
+
+func TestGzipErrorResponse(t *testing.T) {
+	t.Parallel()
+	c := &fbapi.Client{
+		Transport: fTransport(func(r *http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: http.StatusBadRequest,
+				Header:     http.Header{"Content-Encoding": []string{"gzip"}},
+				Body:       ioutil.NopCloser(bytes.NewReader(gzipped(t, `{"error":{"code":100}}`))),
+			}, nil
+		}),
+	}
+	_, err := c.Do(&http.Request{Method: "GET"}, nil)
+	ensure.DeepEqual(t, withoutResponse(err), &fbapi.Error{Code: 100})
+}
+
+func TestGzipInvalidResponse(t *testing.T) {
+	t.Parallel()
+	c := &fbapi.Client{
+		Transport: fTransport(func(r *http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Header:     http.Header{"Content-Encoding": []string{"gzip"}},
+				Body:       ioutil.NopCloser(strings.NewReader(`{}`)),
+			}, nil
+		}),
+	}
+	_, err := c.Do(&http.Request{Method: "GET"}, nil)
+	ensure.NotNil(t, err)
+}
+
+func TestGzipCallerAcceptEncoding(t *testing.T) {
+	t.Parallel()
+	c := &fbapi.Client{
+		Transport: fTransport(func(r *http.Request) (*http.Response, error) {
+			ensure.DeepEqual(t, r.Header.Get("Accept-Encoding"), "identity")
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(strings.NewReader(`{}`)),
+			}, nil
+		}),
+	}
+	_, err := c.Do(&http.Request{
+		Method: "GET",
+		Header: http.Header{"Accept-Encoding": []string{"identity"}},
+	}, nil)
+	ensure.Nil(t, err)
+}
+
+func TestGzipServer(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ensure.DeepEqual(t, r.Header.Get("Accept-Encoding"), "gzip")
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Write(gzipped(t, `{"id":"42"}`))
+	}))
+	defer server.Close()
+	u, err := url.Parse(server.URL)
+	ensure.Nil(t, err)
+	c := &fbapi.Client{BaseURL: u}
+	var actual map[string]string
+	_, err = c.Do(&http.Request{Method: "GET", URL: &url.URL{Path: "42"}}, &actual)
+	ensure.Nil(t, err)
+	ensure.DeepEqual(t, actual, map[string]string{"id": "42"})
+}
+
+func TestNotModified(t *testing.T) {
+	t.Parallel()
+	stats := newRecordingStats()
+	c := &fbapi.Client{
+		Stats: stats,
+		Transport: fTransport(func(r *http.Request) (*http.Response, error) {
+			ensure.DeepEqual(t, r.Header.Get("If-None-Match"), `"abc"`)
+			return &http.Response{
+				StatusCode: http.StatusNotModified,
+				Header:     http.Header{"Etag": []string{`"abc"`}},
+				Body:       ioutil.NopCloser(strings.NewReader("")),
+			}, nil
+		}),
+	}
+	actual := map[string]string{"id": "cached"}
+	res, err := c.Do(&http.Request{
+		Method: "GET",
+		Header: http.Header{"If-None-Match": []string{`"abc"`}},
+	}, &actual)
+	ensure.True(t, err == fbapi.ErrNotModified, err)
+	ensure.DeepEqual(t, res.Header.Get("ETag"), `"abc"`)
+	ensure.DeepEqual(t, actual, map[string]string{"id": "cached"})
+	ensure.DeepEqual(t, stats.counts, map[string]int{"request.GET": 1, "request.GET.status.304": 1})
+}
+
+func TestModifiedETag(t *testing.T) {
+	t.Parallel()
+	c := &fbapi.Client{
+		Transport: fTransport(func(r *http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Header:     http.Header{"Etag": []string{`"def"`}},
+				Body:       ioutil.NopCloser(strings.NewReader(`{"id":"fresh"}`)),
+			}, nil
+		}),
+	}
+	actual := map[string]string{"id": "cached"}
+	res, err := c.Do(&http.Request{
+		Method: "GET",
+		Header: http.Header{"If-None-Match": []string{`"abc"`}},
+	}, &actual)
+	ensure.Nil(t, err)
+	ensure.DeepEqual(t, res.Header.Get("ETag"), `"def"`)
+	ensure.DeepEqual(t, actual, map[string]string{"id": "fresh"})
+}
+
+func TestErrorResponseDetails(t *testing.T) {
+	t.Parallel()
+	header := http.Header{
+		"X-Fb-Debug":    []string{"debug"},
+		"X-Fb-Trace-Id": []string{"trace"},
+	}
+	c := &fbapi.Client{
+		Transport: fTransport(func(r *http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: http.StatusForbidden,
+				Header:     header,
+				Body:       ioutil.NopCloser(strings.NewReader(`{"error":{"code":200}}`)),
+			}, nil
+		}),
+	}
+	_, err := c.Do(&http.Request{Method: "GET"}, nil)
+	apiErr, ok := err.(*fbapi.Error)
+	ensure.True(t, ok, err)
+	ensure.DeepEqual(t, apiErr.HTTPStatus(), http.StatusForbidden)
+	ensure.DeepEqual(t, apiErr.ResponseHeader().Get("X-FB-Debug"), "debug")
+	ensure.DeepEqual(t, apiErr.ResponseHeader().Get("X-FB-Trace-ID"), "trace")
+}
+
+func TestErrorWithoutResponseDetails(t *testing.T) {
+	t.Parallel()
+	var apiErr fbapi.Error
+	ensure.Nil(t, json.Unmarshal([]byte(`{"code":190}`), &apiErr))
+	ensure.DeepEqual(t, apiErr.HTTPStatus(), 0)
+	ensure.True(t, apiErr.ResponseHeader() == nil)
+	ensure.True(t, apiErr.Request() == nil)
+	ensure.True(t, apiErr.Response() == nil)
+}
+
+func TestErrorRequestResponse(t *testing.T) {
+	t.Parallel()
+	c := &fbapi.Client{
+		Transport: fTransport(func(r *http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: http.StatusBadRequest,
+				Body:       ioutil.NopCloser(strings.NewReader(`{"error":{"code":100}}`)),
+			}, nil
+		}),
+	}
+	u, err := url.Parse("https://graph.facebook.com/me?access_token=secret&fields=id")
+	ensure.Nil(t, err)
+	_, err = c.Do(&http.Request{Method: "GET", URL: u}, nil)
+	apiErr, ok := err.(*fbapi.Error)
+	ensure.True(t, ok, err)
+	ensure.DeepEqual(t, apiErr.Response().StatusCode, http.StatusBadRequest)
+	ensure.DeepEqual(t, apiErr.Request().Method, "GET")
+	ensure.DeepEqual(t, apiErr.Request().URL.Query().Get("fields"), "id")
+	ensure.StringDoesNotContain(t, apiErr.Request().URL.String(), "secret")
+	ensure.True(t, apiErr.Response().Request == apiErr.Request())
+	ensure.DeepEqual(t, apiErr.Error(), `fbapi: error code=100 status=400 `+
+		`request="GET https://graph.facebook.com/me?access_token=REDACTED&fields=id"`)
+	ensure.DeepEqual(t, u.Query().Get("access_token"), "secret")
+}
@@ -0,0 +1,144 @@
+package fbapi
+
+import (
+	"encoding/json"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type paramDebug string
+
+func (p paramDebug) Set(values url.Values) error {
+	if p != "" {
+		values.Set("debug", string(p))
+	}
+	return nil
+}
+
+// ParamDebug specifies the debug parameter. Using "all" includes warnings
+// about the request in the __debug__ object of the response.
+func ParamDebug(level string) Param {
+	return paramDebug(level)
+}
+
+// DebugMessage is a message in the __debug__ object of a response.
+type DebugMessage struct {
+	Type    string `json:"type"`
+	Message string `json:"message"`
+	Link    string `json:"link"`
+}
+
+// DroppedFields returns the requested fields which are missing from the
+// response body and mentioned in a debug message. Such fields were silently
+// dropped, typically for lack of permissions, rather than being empty. The
+// request must be made with ParamDebug("all") for the debug messages to be
+// included. Only the top level fields are checked, modifiers and nested
+// fields are ignored.
+func DroppedFields(body []byte, fields ...string) ([]string, error) {
+	var response map[string]json.RawMessage
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, err
+	}
+
+	var debug struct {
+		Messages []DebugMessage `json:"messages"`
+	}
+	if raw := response["__debug__"]; raw != nil {
+		if err := json.Unmarshal(raw, &debug); err != nil {
+			return nil, err
+		}
+	}
+
+	var dropped []string
+	for _, field := range fields {
+		if i := strings.IndexAny(field, ".{"); i != -1 {
+			field = field[:i]
+		}
+		if _, ok := response[field]; ok {
+			continue
+		}
+		mention := regexp.MustCompile(`\b` + regexp.QuoteMeta(field) + `\b`)
+		for _, m := range debug.Messages {
+			if mention.MatchString(m.Message) {
+				dropped = append(dropped, field)
+				break
+			}
+		}
+	}
+	return dropped, nil
+}
+
+// NestedError is an error returned in place of a field of an otherwise
+// successful response, typically for a field expansion the token isn't
+// allowed to read. The Path to the field has the keys and array indexes
+// separated by dots, for example "data.0.comments".
+type NestedError struct {
+	Path  string
+	Error *Error
+}
+
+// NestedErrors returns the errors found in the response body below the top
+// level, ordered by path. Such errors are otherwise easy to miss, as decoding
+// the body into a result typically ignores them.
+func NestedErrors(body []byte) ([]NestedError, error) {
+	var errs []NestedError
+	if err := findNestedErrors(body, "", &errs); err != nil {
+		return nil, err
+	}
+	return errs, nil
+}
+
+// Collect the errors in the value at the given path, which is empty for the
+// top level.
+func findNestedErrors(raw json.RawMessage, path string, errs *[]NestedError) error {
+	join := func(key string) string {
+		if path == "" {
+			return key
+		}
+		return path + "." + key
+	}
+
+	raw = json.RawMessage(strings.TrimSpace(string(raw)))
+	if len(raw) == 0 {
+		return nil
+	}
+	switch raw[0] {
+	case '{':
+		var object map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &object); err != nil {
+			return err
+		}
+		if path != "" {
+			if apiError := findError(raw, defaultErrorPath); apiError != nil {
+				*errs = append(*errs, NestedError{Path: path, Error: apiError})
+			}
+		}
+		keys := make([]string, 0, len(object))
+		for key := range object {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if key == defaultErrorPath {
+				continue
+			}
+			if err := findNestedErrors(object[key], join(key), errs); err != nil {
+				return err
+			}
+		}
+	case '[':
+		var array []json.RawMessage
+		if err := json.Unmarshal(raw, &array); err != nil {
+			return err
+		}
+		for i, value := range array {
+			if err := findNestedErrors(value, join(strconv.Itoa(i)), errs); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package fbapi_test
+
+import (
+	"testing"
+
+	"github.com/facebookgo/ensure"
+	"github.com/facebookgo/fbapi"
+)
+
+func TestParamDebug(t *testing.T) {
+	t.Parallel()
+	v, err := fbapi.ParamValues(fbapi.ParamDebug("all"))
+	ensure.Nil(t, err)
+	ensure.DeepEqual(t, v.Get("debug"), "all")
+}
+
+func TestDroppedFields(t *testing.T) {
+	t.Parallel()
+	body := []byte(`{
+		"id": "1",
+		"name": "Jane",
+		"__debug__": {
+			"messages": [
+				{
+					"link": "https://developers.facebook.com/docs/apps/changelog/",
+					"message": "Requires the user_birthday permission to access the field birthday.",
+					"type": "warning"
+				}
+			]
+		}
+	}`)
+	dropped, err := fbapi.DroppedFields(body, "id", "name", "email", "birthday", "friends{id}")
+	ensure.Nil(t, err)
+	ensure.DeepEqual(t, dropped, []string{"birthday"})
+}
+
+func TestDroppedFieldsNoDebug(t *testing.T) {
+	t.Parallel()
+	dropped, err := fbapi.DroppedFields([]byte(`{"id":"1"}`), "id", "birthday")
+	ensure.Nil(t, err)
+	ensure.True(t, dropped == nil)
+}
+
+func TestDroppedFieldsInvalidBody(t *testing.T) {
+	t.Parallel()
+	_, err := fbapi.DroppedFields([]byte(`[]`), "id")
+	ensure.NotNil(t, err)
+}
+
+func TestNestedErrors(t *testing.T) {
+	t.Parallel()
+	body := []byte(`
+		{
+			"id": "1",
+			"friends": {"error": {"message": "Requires user_friends", "code": 10}},
+			"posts": {
+				"data": [
+					{"id": "1_1"},
+					{"id": "1_2", "comments": {"error": {"code": 200, "error_subcode": 1}}}
+				]
+			},
+			"error": null
+		}`)
+	errs, err := fbapi.NestedErrors(body)
+	ensure.Nil(t, err)
+	ensure.DeepEqual(t, errs, []fbapi.NestedError{
+		{Path: "friends", Error: &fbapi.Error{Message: "Requires user_friends", Code: 10}},
+		{Path: "posts.data.1.comments", Error: &fbapi.Error{Code: 200, Subcode: 1}},
+	})
+}
+
+func TestNestedErrorsNone(t *testing.T) {
+	t.Parallel()
+	errs, err := fbapi.NestedErrors([]byte(`{"id":"1","data":[1,"error",{"error":"not an object"}]}`))
+	ensure.Nil(t, err)
+	ensure.True(t, errs == nil)
+}
+
+func TestNestedErrorsInvalidBody(t *testing.T) {
+	t.Parallel()
+	_, err := fbapi.NestedErrors([]byte(`{"id":`))
+	ensure.NotNil(t, err)
+}
@@ -0,0 +1,44 @@
+package fbapi
+
+import "errors"
+
+// IsOAuthError returns true if err is, or wraps, an *Error indicating the
+// access token is invalid, expired or was revoked, in which case a new token
+// must be obtained. These have code 190, or 102 for older API versions.
+func IsOAuthError(err error) bool {
+	var apiErr *Error
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	return apiErr.Code == 102 || apiErr.Code == 190
+}
+
+// IsRateLimitError returns true if err is, or wraps, an *Error indicating a
+// rate limit was reached, in which case requests should be slowed down. These
+// are the app, user, page and custom limits, with codes 4, 17, 32 and 613, and
+// the business use case limits, with codes 80000 to 80014.
+func IsRateLimitError(err error) bool {
+	var apiErr *Error
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	switch code := apiErr.Code; {
+	case code == 4, code == 17, code == 32, code == 613:
+		return true
+	case code >= 80000 && code <= 80014:
+		return true
+	}
+	return false
+}
+
+// IsPermissionError returns true if err is, or wraps, an *Error indicating a
+// permission is missing, either on the access token or for the object. These
+// have code 10, or a code from 200 to 299. Note they typically have the
+// OAuthException type too, but retrying with a new token won't help.
+func IsPermissionError(err error) bool {
+	var apiErr *Error
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	return apiErr.Code == 10 || (apiErr.Code >= 200 && apiErr.Code <= 299)
+}
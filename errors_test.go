@@ -0,0 +1,43 @@
+package fbapi_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/facebookgo/ensure"
+	"github.com/facebookgo/fbapi"
+)
+
+func TestErrorClassification(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		Err        error
+		OAuth      bool
+		RateLimit  bool
+		Permission bool
+	}{
+		{Err: &fbapi.Error{Code: 190, Subcode: 463, Type: "OAuthException"}, OAuth: true},
+		{Err: &fbapi.Error{Code: 102}, OAuth: true},
+		{Err: &fbapi.Error{Code: 4}, RateLimit: true},
+		{Err: &fbapi.Error{Code: 17}, RateLimit: true},
+		{Err: &fbapi.Error{Code: 32}, RateLimit: true},
+		{Err: &fbapi.Error{Code: 613}, RateLimit: true},
+		{Err: &fbapi.Error{Code: 80004}, RateLimit: true},
+		{Err: &fbapi.Error{Code: 10, Type: "OAuthException"}, Permission: true},
+		{Err: &fbapi.Error{Code: 200, Type: "OAuthException"}, Permission: true},
+		{Err: &fbapi.Error{Code: 299}, Permission: true},
+		{Err: fmt.Errorf("fetching feed: %w", &fbapi.Error{Code: 190}), OAuth: true},
+		{Err: fmt.Errorf("fetching feed: %w", &fbapi.Error{Code: 4}), RateLimit: true},
+		{Err: fmt.Errorf("fetching feed: %w", &fbapi.Error{Code: 200}), Permission: true},
+		{Err: &fbapi.Error{Code: 100}},
+		{Err: &fbapi.Error{Code: 80015}},
+		{Err: errors.New("code 190")},
+		{Err: nil},
+	}
+	for _, tc := range cases {
+		ensure.DeepEqual(t, fbapi.IsOAuthError(tc.Err), tc.OAuth, tc.Err)
+		ensure.DeepEqual(t, fbapi.IsRateLimitError(tc.Err), tc.RateLimit, tc.Err)
+		ensure.DeepEqual(t, fbapi.IsPermissionError(tc.Err), tc.Permission, tc.Err)
+	}
+}
@@ -0,0 +1,28 @@
+package fbbatch
+
+import "github.com/facebookgo/fbapi"
+
+// Decode unmarshals the body of each Response into the result at the same
+// index, the same way the Client would. The returned errors also correspond to
+// the responses by index, and are nil for those that were decoded successfully.
+// Responses without a corresponding result, or with a nil one, are discarded.
+func Decode(responses []*Response, results []interface{}) []error {
+	errs := make([]error, len(responses))
+	for i, r := range responses {
+		if r == nil {
+			errs[i] = errMissingResponse
+			continue
+		}
+		var result interface{}
+		if i < len(results) {
+			result = results[i]
+		}
+		hres, err := r.httpResponse()
+		if err != nil {
+			errs[i] = err
+			continue
+		}
+		errs[i] = fbapi.UnmarshalResponse(hres, result)
+	}
+	return errs
+}
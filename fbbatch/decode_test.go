@@ -0,0 +1,45 @@
+package fbbatch
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/facebookgo/ensure"
+	"github.com/facebookgo/fbapi"
+)
+
+func TestDecode(t *testing.T) {
+	responses := []*Response{
+		{Code: http.StatusOK, Body: `{"id":"1","name":"Jane"}`},
+		{Code: http.StatusOK, Body: `{"data":[{"id":"2"},{"id":"3"}]}`},
+		{Code: http.StatusOK, Body: `{"success":true}`},
+		{Code: http.StatusBadRequest, Body: `{"error":{"code":100,"message":"m"}}`},
+		nil,
+	}
+	var user struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	}
+	var friends struct {
+		Data []struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	var success struct {
+		Success bool `json:"success"`
+	}
+	var failed map[string]interface{}
+	errs := Decode(responses, []interface{}{&user, &friends, &success, &failed})
+	ensure.DeepEqual(t, len(errs), len(responses))
+	ensure.Nil(t, errs[0])
+	ensure.Nil(t, errs[1])
+	ensure.Nil(t, errs[2])
+	ensure.DeepEqual(t, withoutResponse(errs[3]), &fbapi.Error{Code: 100, Message: "m"})
+	ensure.True(t, errs[4] == errMissingResponse)
+	ensure.DeepEqual(t, user.ID, "1")
+	ensure.DeepEqual(t, user.Name, "Jane")
+	ensure.DeepEqual(t, len(friends.Data), 2)
+	ensure.DeepEqual(t, friends.Data[1].ID, "3")
+	ensure.True(t, success.Success)
+	ensure.True(t, failed == nil)
+}
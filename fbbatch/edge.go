@@ -0,0 +1,101 @@
+package fbbatch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"strconv"
+)
+
+var errMissingResponse = errors.New("fbbatch: missing response in batch")
+
+// PageResult is the outcome of fetching the first page of an edge for an id,
+// either the data of the page or the error.
+type PageResult struct {
+	Data []json.RawMessage
+	Err  error
+}
+
+// FirstPages fetches the first page of the given edge for each of the ids,
+// issuing as many Batch calls as necessary. The results are returned keyed by
+// id, so the failed ids can be retried. An error is returned only if all the
+// Batch calls failed, the error of the call if there was one or ChunkErrors
+// otherwise. If only some of the calls fail, the affected ids have the
+// corresponding ChunkError, indexing the ids. Limit is sent as is, use 0 to
+// get the API default page size.
+func (c *Client) FirstPages(ctx context.Context, ids []string, edge string, limit uint64) (map[string]PageResult, error) {
+	maxBatchSize := int(c.MaxBatchSize)
+	if maxBatchSize == 0 {
+		maxBatchSize = defaultMaxBatchSize
+	}
+	if maxBatchSize > maxBatchRequests {
+		maxBatchSize = maxBatchRequests
+	}
+
+	query := make(url.Values)
+	if limit != 0 {
+		query.Set("limit", strconv.FormatUint(limit, 10))
+	}
+	suffix := "/" + url.PathEscape(edge)
+	if len(query) != 0 {
+		suffix += "?" + query.Encode()
+	}
+
+	results := make(map[string]PageResult, len(ids))
+	var errs ChunkErrors
+	for start := 0; start < len(ids); start += maxBatchSize {
+		end := start + maxBatchSize
+		if end > len(ids) {
+			end = len(ids)
+		}
+
+		b := &Batch{
+			AccessToken: c.AccessToken,
+			AppID:       c.AppID,
+			Request:     make([]*Request, end-start),
+		}
+		for i, id := range ids[start:end] {
+			b.Request[i] = &Request{
+				Method:      "GET",
+				RelativeURL: url.PathEscape(id) + suffix,
+			}
+		}
+
+		res, err := c.batchDo(ctx, b)
+		if err != nil {
+			chunkErr := &ChunkError{Start: start, End: end, Err: err}
+			errs = append(errs, chunkErr)
+			for _, id := range ids[start:end] {
+				results[id] = PageResult{Err: chunkErr}
+			}
+			continue
+		}
+
+		pages := make([]struct {
+			Data []json.RawMessage `json:"data"`
+		}, end-start)
+		decoded := make([]interface{}, len(pages))
+		for i := range pages {
+			decoded[i] = &pages[i]
+		}
+		errs := Decode(res, decoded)
+		for i, id := range ids[start:end] {
+			switch {
+			case i >= len(errs):
+				results[id] = PageResult{Err: errMissingResponse}
+			case errs[i] != nil:
+				results[id] = PageResult{Err: errs[i]}
+			default:
+				results[id] = PageResult{Data: pages[i].Data}
+			}
+		}
+	}
+	if len(ids) != 0 && errs.all(len(ids)) {
+		if len(errs) == 1 {
+			return nil, errs[0].Err
+		}
+		return nil, errs
+	}
+	return results, nil
+}
@@ -0,0 +1,157 @@
+package fbbatch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/facebookgo/ensure"
+	"github.com/facebookgo/fbapi"
+	"github.com/facebookgo/jsonpipe"
+)
+
+func TestFirstPages(t *testing.T) {
+	ids := []string{"1", "2", "3", "4", "5"}
+	var calls int
+	c := &Client{
+		MaxBatchSize: 2,
+		Client: &fbapi.Client{
+			Transport: fTransport(func(r *http.Request) (*http.Response, error) {
+				calls++
+				ensure.Nil(t, r.ParseForm())
+				var requests []*Request
+				ensure.Nil(t, json.Unmarshal([]byte(r.PostFormValue("batch")), &requests))
+				var responses []*Response
+				for _, req := range requests {
+					ensure.True(t, strings.HasSuffix(req.RelativeURL, "/friends?limit=3"), req.RelativeURL)
+					id := strings.TrimSuffix(req.RelativeURL, "/friends?limit=3")
+					body := fmt.Sprintf(`{"data":[{"id":"friend-of-%s"}]}`, id)
+					responses = append(responses, &Response{Code: http.StatusOK, Body: body})
+				}
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       ioutil.NopCloser(jsonpipe.Encode(responses)),
+				}, nil
+			}),
+		},
+	}
+	pages, err := c.FirstPages(context.Background(), ids, "friends", 3)
+	ensure.Nil(t, err)
+	ensure.DeepEqual(t, calls, 3)
+	ensure.DeepEqual(t, len(pages), len(ids))
+	for _, id := range ids {
+		ensure.Nil(t, pages[id].Err)
+		ensure.DeepEqual(t, len(pages[id].Data), 1)
+		ensure.DeepEqual(t, string(pages[id].Data[0]), fmt.Sprintf(`{"id":"friend-of-%s"}`, id))
+	}
+}
+
+func TestFirstPagesEscapesPath(t *testing.T) {
+	c := &Client{
+		Client: &fbapi.Client{
+			Transport: fTransport(func(r *http.Request) (*http.Response, error) {
+				ensure.Nil(t, r.ParseForm())
+				var requests []*Request
+				ensure.Nil(t, json.Unmarshal([]byte(r.PostFormValue("batch")), &requests))
+				ensure.DeepEqual(t, len(requests), 1)
+				ensure.DeepEqual(t, requests[0].RelativeURL, "a%2Fb%3F/c%20d")
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       ioutil.NopCloser(strings.NewReader(`[{"code":200,"body":"{\"data\":[]}"}]`)),
+				}, nil
+			}),
+		},
+	}
+	pages, err := c.FirstPages(context.Background(), []string{"a/b?"}, "c d", 0)
+	ensure.Nil(t, err)
+	ensure.Nil(t, pages["a/b?"].Err)
+}
+
+func TestFirstPagesError(t *testing.T) {
+	c := &Client{
+		Client: &fbapi.Client{
+			Transport: fTransport(func(r *http.Request) (*http.Response, error) {
+				responses := []*Response{
+					{Code: http.StatusOK, Body: `{"data":[]}`},
+					{Code: http.StatusBadRequest, Body: `{"error":{"code":100}}`},
+				}
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       ioutil.NopCloser(jsonpipe.Encode(responses)),
+				}, nil
+			}),
+		},
+	}
+	pages, err := c.FirstPages(context.Background(), []string{"1", "2"}, "friends", 0)
+	ensure.Nil(t, err)
+	ensure.Nil(t, pages["1"].Err)
+	ensure.DeepEqual(t, len(pages["1"].Data), 0)
+	ensure.DeepEqual(t, withoutResponse(pages["2"].Err), &fbapi.Error{Code: 100})
+}
+
+func TestFirstPagesBatchError(t *testing.T) {
+	givenErr := errors.New("batch failed")
+	var calls int
+	c := &Client{
+		MaxBatchSize: 1,
+		Client: &fbapi.Client{
+			Transport: fTransport(func(r *http.Request) (*http.Response, error) {
+				calls++
+				if calls == 2 {
+					return nil, givenErr
+				}
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       ioutil.NopCloser(strings.NewReader(`[{"code":200,"body":"{\"data\":[1]}"}]`)),
+				}, nil
+			}),
+		},
+	}
+	pages, err := c.FirstPages(context.Background(), []string{"1", "2", "3"}, "friends", 0)
+	ensure.Nil(t, err)
+	ensure.DeepEqual(t, string(pages["1"].Data[0]), "1")
+	ensure.DeepEqual(t, pages["2"].Err, &ChunkError{Start: 1, End: 2, Err: givenErr})
+	ensure.DeepEqual(t, string(pages["3"].Data[0]), "1")
+
+	c.MaxBatchSize = 0
+	calls = 1
+	_, err = c.FirstPages(context.Background(), []string{"1", "2"}, "friends", 0)
+	ensure.True(t, err == givenErr, err)
+}
+
+func TestFirstPagesAllChunksFail(t *testing.T) {
+	givenErr := errors.New("batch failed")
+	c := &Client{
+		MaxBatchSize: 1,
+		Client: &fbapi.Client{
+			Transport: fTransport(func(r *http.Request) (*http.Response, error) {
+				return nil, givenErr
+			}),
+		},
+	}
+	pages, err := c.FirstPages(context.Background(), []string{"1", "2"}, "friends", 0)
+	ensure.True(t, pages == nil)
+	ensure.DeepEqual(t, err, ChunkErrors{
+		{Start: 0, End: 1, Err: givenErr},
+		{Start: 1, End: 2, Err: givenErr},
+	})
+}
+
+func TestFirstPagesContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	c := &Client{
+		Client: &fbapi.Client{
+			Transport: fTransport(func(r *http.Request) (*http.Response, error) {
+				return nil, r.Context().Err()
+			}),
+		},
+	}
+	_, err := c.FirstPages(ctx, []string{"1"}, "friends", 0)
+	ensure.DeepEqual(t, err, context.Canceled)
+}
@@ -10,8 +10,13 @@
 package fbbatch
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"io/ioutil"
+	"mime/multipart"
 	"net/http"
 	"net/url"
 	"strconv"
@@ -27,6 +32,28 @@ const (
 	defaultPendingWorkCapacity = 1000
 	defaultBatchTimeout        = time.Millisecond * 10
 	defaultMaxBatchSize        = 50
+	defaultBatchRetryDelay     = time.Second
+	maxBatchRequests           = 50
+)
+
+// ErrOverloaded is returned by Client.Do for requests rejected or dropped
+// because too many requests are pending.
+var ErrOverloaded = errors.New("fbbatch: too many pending requests")
+
+// OverloadPolicy determines what happens to requests made when the pending work
+// queue is full.
+type OverloadPolicy int
+
+const (
+	// Block waits until there is room in the queue. This is the default.
+	Block OverloadPolicy = iota
+
+	// Reject fails the request with ErrOverloaded.
+	Reject
+
+	// DropOldest drops the oldest pending request to make room, failing it with
+	// ErrOverloaded.
+	DropOldest
 )
 
 // Request in a Batch.
@@ -35,6 +62,71 @@ type Request struct {
 	Method      string `json:"method,omitempty"`
 	RelativeURL string `json:"relative_url"`
 	Body        string `json:"body,omitempty"`
+
+	// Controls whether the response of a named request is returned when it
+	// succeeds. The API omits them by default for requests referenced by
+	// others, set this to include them anyway, or to omit other responses.
+	OmitResponseOnSuccess *bool `json:"omit_response_on_success,omitempty"`
+
+	// When set, this is appended to the relative URL as the access_token
+	// parameter, taking precedence over the Batch AccessToken. This allows
+	// making requests for many users in one Batch. It is a secret and is
+	// redacted when the Request is formatted.
+	AccessToken string `json:"-"`
+
+	// Files uploaded along with the request, for example the source of a
+	// photo. When any request has files BatchDo sends the Batch as a multipart
+	// request. Requests made through the Client have none.
+	Files []*File `json:"-"`
+}
+
+// File attached to a Request in a Batch.
+type File struct {
+	// The name of the file part, which must be unique within the Batch.
+	Name string
+
+	// The filename sent for the file part.
+	Filename string
+
+	// The content of the file, streamed when the Batch is sent.
+	Body io.Reader
+}
+
+// MarshalJSON includes the AccessToken in the relative URL. It is appended
+// rather than merged into the query to preserve any result references.
+func (r Request) MarshalJSON() ([]byte, error) {
+	if r.AccessToken != "" {
+		sep := "?"
+		if strings.Contains(r.RelativeURL, "?") {
+			sep = "&"
+		}
+		r.RelativeURL += sep + "access_token=" + url.QueryEscape(r.AccessToken)
+	}
+	type request Request
+	names := make([]string, len(r.Files))
+	for i, f := range r.Files {
+		names[i] = f.Name
+	}
+	return json.Marshal(struct {
+		request
+		AttachedFiles string `json:"attached_files,omitempty"`
+	}{
+		request:       request(r),
+		AttachedFiles: strings.Join(names, ","),
+	})
+}
+
+func (r Request) String() string {
+	r.AccessToken = fbapi.RedactToken(r.AccessToken)
+	type request Request
+	return fmt.Sprintf("%+v", request(r))
+}
+
+// GoString redacts the AccessToken for the %#v verb.
+func (r Request) GoString() string {
+	r.AccessToken = fbapi.RedactToken(r.AccessToken)
+	type request Request
+	return fmt.Sprintf("%#v", request(r))
 }
 
 // Make a Batch Request from an *http.Request.
@@ -60,20 +152,59 @@ func newRequest(hr *http.Request) (*Request, error) {
 	return req, nil
 }
 
+// NewBatchRequest makes a Request for the method and path using the params.
+// The params are sent in the relative URL query for GET and DELETE requests,
+// and in the body for other methods such as POST. Result references in the
+// params, as returned by Batch.Result, are kept unescaped.
+func NewBatchRequest(method, path string, params ...fbapi.Param) (*Request, error) {
+	v, err := fbapi.ParamValues(params...)
+	if err != nil {
+		return nil, err
+	}
+
+	req := &Request{
+		Method:      method,
+		RelativeURL: path,
+	}
+	if len(v) == 0 {
+		return req, nil
+	}
+
+	switch method {
+	case "GET", "DELETE":
+		u, err := url.Parse(path)
+		if err != nil {
+			return nil, err
+		}
+		query := u.Query()
+		for key, values := range v {
+			query[key] = values
+		}
+		u.RawQuery = unescapeResults(query.Encode())
+		req.RelativeURL = u.String()
+	default:
+		req.Body = unescapeResults(v.Encode())
+	}
+	return req, nil
+}
+
 // Header in a Batch Response.
 type Header struct {
 	Name  string `json:"name"`
 	Value string `json:"value"`
 }
 
-// Response in a Batch.
+// Response in a Batch. The Header will be empty if the Batch was made with
+// OmitHeaders set.
 type Response struct {
 	Code   int      `json:"code"`
 	Header []Header `json:"headers"`
 	Body   string   `json:"body,omitempty"`
 }
 
-// Convert the Batch Response to a *http.Response or possibly an error.
+// Convert the Batch Response to a *http.Response or possibly an error. All
+// headers are preserved, and the status line and content length match the
+// Response as they would for a request made outside a batch.
 func (r *Response) httpResponse() (*http.Response, error) {
 	header := make(http.Header)
 	for _, h := range r.Header {
@@ -81,7 +212,7 @@ func (r *Response) httpResponse() (*http.Response, error) {
 	}
 
 	res := &http.Response{
-		Status:        http.StatusText(r.Code),
+		Status:        fmt.Sprintf("%d %s", r.Code, http.StatusText(r.Code)),
 		StatusCode:    r.Code,
 		Proto:         "HTTP/1.1",
 		ProtoMajor:    1,
@@ -98,11 +229,61 @@ type Batch struct {
 	AccessToken string
 	AppID       uint64
 	Request     []*Request
+
+	// Sends include_headers=false, which omits the headers of the responses
+	// to reduce their size. The Header of each Response will then be empty.
+	OmitHeaders bool
 }
 
 // BatchDo performs a Batch call. Errors are only returned if the batch itself
 // fails, not for the individual requests.
+//
+// The API limits the number of requests in a batch to 50. Batches with more
+// requests are transparently split into chunks of 50 requests, each sent in
+// its own call, and the responses are returned in order. If some of the calls
+// fail, the responses of the others are still returned along with a
+// ChunkErrors identifying the failed chunks, whose responses are nil.
 func BatchDo(c *fbapi.Client, b *Batch) ([]*Response, error) {
+	return chunkedBatchDo(b, func(b *Batch) ([]*Response, error) {
+		_, responses, err := batchDo(context.Background(), c, b)
+		return responses, err
+	})
+}
+
+// Perform the Batch call in chunks of at most 50 requests using do.
+func chunkedBatchDo(b *Batch, do func(*Batch) ([]*Response, error)) ([]*Response, error) {
+	if len(b.Request) <= maxBatchRequests {
+		return do(b)
+	}
+
+	responses := make([]*Response, len(b.Request))
+	var errs ChunkErrors
+	for start := 0; start < len(b.Request); start += maxBatchRequests {
+		end := start + maxBatchRequests
+		if end > len(b.Request) {
+			end = len(b.Request)
+		}
+		chunk := *b
+		chunk.Request = b.Request[start:end]
+		res, err := do(&chunk)
+		if err != nil {
+			errs = append(errs, &ChunkError{Start: start, End: end, Err: err})
+			continue
+		}
+		copy(responses[start:end], res)
+	}
+	if errs != nil {
+		return responses, errs
+	}
+	return responses, nil
+}
+
+// Perform a single Batch call, also returning the response of the call itself.
+func batchDo(ctx context.Context, c *fbapi.Client, b *Batch) (*http.Response, []*Response, error) {
+	if err := b.checkNames(); err != nil {
+		return nil, nil, err
+	}
+
 	v := make(url.Values)
 
 	if b.AccessToken != "" {
@@ -111,25 +292,127 @@ func BatchDo(c *fbapi.Client, b *Batch) ([]*Response, error) {
 	if b.AppID != 0 {
 		v.Add("batch_app_id", strconv.FormatUint(b.AppID, 10))
 	}
+	if b.OmitHeaders {
+		v.Add("include_headers", "false")
+	}
 
 	j, err := json.Marshal(b.Request)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	v.Add("batch", string(j))
 
-	req, err := http.NewRequest("POST", "/", strings.NewReader(v.Encode()))
-	if err != nil {
-		return nil, err
+	var files []*File
+	for _, r := range b.Request {
+		files = append(files, r.Files...)
+	}
+	var req *http.Request
+	if len(files) == 0 {
+		req, err = http.NewRequest("POST", "/", strings.NewReader(v.Encode()))
+		if err != nil {
+			return nil, nil, err
+		}
+		req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+	} else {
+		req, err = newMultipartRequest(v, files)
+		if err != nil {
+			return nil, nil, err
+		}
 	}
-	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
 
 	responses := make([]*Response, len(b.Request))
-	_, err = c.Do(req, &responses)
+	res, err := c.DoContext(ctx, req, &responses)
+	if err != nil {
+		return res, nil, err
+	}
+	return res, responses, nil
+}
+
+// Make a multipart Batch request with the given values and files, which are
+// streamed rather than buffered.
+func newMultipartRequest(v url.Values, files []*File) (*http.Request, error) {
+	pr, pw := io.Pipe()
+	mw := multipart.NewWriter(pw)
+	go func() {
+		for key, values := range v {
+			for _, value := range values {
+				if err := mw.WriteField(key, value); err != nil {
+					pw.CloseWithError(err)
+					return
+				}
+			}
+		}
+		for _, f := range files {
+			fw, err := mw.CreateFormFile(f.Name, f.Filename)
+			if err != nil {
+				pw.CloseWithError(err)
+				return
+			}
+			if _, err := io.Copy(fw, f.Body); err != nil {
+				pw.CloseWithError(err)
+				return
+			}
+		}
+		pw.CloseWithError(mw.Close())
+	}()
+
+	req, err := http.NewRequest("POST", "/", pr)
 	if err != nil {
+		pr.Close()
 		return nil, err
 	}
-	return responses, nil
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	return req, nil
+}
+
+// ChunkError is the failure of one of the calls made for a Batch split into
+// chunks.
+type ChunkError struct {
+	// The indexes of the first request in the chunk and of the one after the
+	// last.
+	Start, End int
+
+	Err error
+}
+
+func (e *ChunkError) Error() string {
+	return fmt.Sprintf("fbbatch: requests %d to %d failed: %s", e.Start, e.End-1, e.Err)
+}
+
+// ChunkErrors is returned by BatchDo when some of the calls made for a Batch
+// split into chunks failed.
+type ChunkErrors []*ChunkError
+
+func (e ChunkErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Reports whether the failed chunks cover all of the n requests of the Batch.
+func (e ChunkErrors) all(n int) bool {
+	var failed int
+	for _, err := range e {
+		failed += err.End - err.Start
+	}
+	return failed == n
+}
+
+// Returns the error applicable to the request at index i given the error
+// returned by BatchDo.
+func requestError(err error, i int) error {
+	errs, ok := err.(ChunkErrors)
+	if !ok {
+		return err
+	}
+	for _, e := range errs {
+		if i >= e.Start && i < e.End {
+			return e
+		}
+	}
+	return errMissingResponse
 }
 
 type workResponse struct {
@@ -161,14 +444,37 @@ func (m *musterBatch) Fire(notifier muster.Notifier) {
 	for i, rr := range m.WorkRequests {
 		b.Request[i] = rr.Request
 	}
-	res, err := BatchDo(m.Client.Client, b)
+	start := time.Now()
+	res, err := m.Client.batchDo(context.Background(), b)
+	if m.Client.Stats != nil {
+		m.Client.recordBatch(len(b.Request), res, err, time.Since(start))
+	}
 	for i, rr := range m.WorkRequests {
-		if err == nil {
+		switch {
+		case i < len(res) && res[i] != nil:
 			rr.Response <- &workResponse{Response: res[i]}
-		} else {
-			rr.Response <- &workResponse{Error: err}
+		case err != nil:
+			rr.Response <- &workResponse{Error: requestError(err, i)}
+		default:
+			rr.Response <- &workResponse{Error: errMissingResponse}
+		}
+	}
+}
+
+// Record the outcome of a fired batch to the Stats.
+func (c *Client) recordBatch(size int, res []*Response, err error, d time.Duration) {
+	c.Stats.Record("batch.size", float64(size))
+	c.Stats.Record("batch.time", float64(d)/float64(time.Millisecond))
+	if err != nil {
+		c.Stats.Inc("batch.error")
+	}
+	var failed int
+	for i := 0; i < size; i++ {
+		if i >= len(res) || res[i] == nil || res[i].Code < 200 || res[i].Code > 399 {
+			failed++
 		}
 	}
+	c.Stats.Record("batch.request_errors", float64(failed))
 }
 
 // Client with the same interface as fbapi.Client but one where the underlying
@@ -178,7 +484,8 @@ type Client struct {
 	AccessToken string
 	AppID       uint64
 
-	// Capacity of log channel. Defaults to 1000.
+	// Capacity of log channel. Defaults to 1000. The channel is a FIFO queue,
+	// so requests enter batches in the order they are queued.
 	PendingWorkCapacity uint
 
 	// Maximum number of items in a batch. Defaults to 50.
@@ -187,6 +494,29 @@ type Client struct {
 	// Amount of time after which to send a pending batch. Defaults to 10ms.
 	BatchTimeout time.Duration
 
+	// What to do when PendingWorkCapacity requests are already pending.
+	// Defaults to Block.
+	Overload OverloadPolicy
+
+	// Used to record metrics for each batch of requests sent. The number of
+	// requests is recorded as batch.size, the duration of the Batch call,
+	// including retries, as batch.time in milliseconds, and the number of
+	// requests without a successful response as batch.request_errors. Batch
+	// calls which fail are counted as batch.error. When nil nothing is
+	// recorded.
+	Stats fbapi.Stats
+
+	// Number of times a Batch call is retried when the call itself fails
+	// because of a rate limit or because the API is temporarily unavailable.
+	// Failures of the individual requests are never retried, nor are Batches
+	// with attached files. Defaults to 0, no retries.
+	BatchRetries int
+
+	// The delay before the first Batch retry, doubled for each following one,
+	// unless the response specifies one with a Retry-After header. Defaults
+	// to 1s.
+	BatchRetryDelay time.Duration
+
 	startOnce sync.Once
 	startErr  error
 	muster    muster.Client
@@ -226,10 +556,106 @@ func (c *Client) Stop() error {
 	return c.muster.Stop()
 }
 
+// Perform the Batch call, in chunks as needed, retrying failed calls as per
+// BatchRetries. Waiting for a retry stops when the context is done, returning
+// its error.
+func (c *Client) batchDo(ctx context.Context, b *Batch) ([]*Response, error) {
+	return chunkedBatchDo(b, func(b *Batch) ([]*Response, error) {
+		for retry := 0; ; retry++ {
+			res, responses, err := batchDo(ctx, c.Client, b)
+			if err == nil || retry >= c.BatchRetries || !retryableBatch(b, res, err) {
+				return responses, err
+			}
+			t := time.NewTimer(c.batchRetryDelay(retry, res))
+			select {
+			case <-t.C:
+			case <-ctx.Done():
+				t.Stop()
+				return nil, ctx.Err()
+			}
+		}
+	})
+}
+
+// Check if the failed Batch call can safely be retried. Only failures where
+// the batch wasn't processed qualify.
+func retryableBatch(b *Batch, res *http.Response, err error) bool {
+	for _, r := range b.Request {
+		if len(r.Files) > 0 {
+			return false
+		}
+	}
+	if fbapi.IsRateLimitError(err) {
+		return true
+	}
+	if apiErr, ok := err.(*fbapi.Error); ok && apiErr.Code == 2 {
+		return true
+	}
+	return res != nil && (res.StatusCode == http.StatusTooManyRequests ||
+		res.StatusCode == http.StatusServiceUnavailable)
+}
+
+// The delay before the given Batch retry, starting at 0.
+func (c *Client) batchRetryDelay(retry int, res *http.Response) time.Duration {
+	if d, ok := fbapi.RetryAfter(res); ok {
+		return d
+	}
+	delay := c.BatchRetryDelay
+	if delay == 0 {
+		delay = defaultBatchRetryDelay
+	}
+	return delay << uint(retry)
+}
+
+// Add the work request to the pending work queue as per the Overload policy.
+// Blocking stops when the context is done, returning its error.
+func (c *Client) enqueue(ctx context.Context, wr *workRequest) error {
+	switch c.Overload {
+	case Reject:
+		select {
+		case c.muster.Work <- wr:
+			return nil
+		default:
+			return ErrOverloaded
+		}
+	case DropOldest:
+		for {
+			select {
+			case c.muster.Work <- wr:
+				return nil
+			default:
+			}
+			select {
+			case oldest := <-c.muster.Work:
+				oldest.(*workRequest).Response <- &workResponse{Error: ErrOverloaded}
+			default:
+			}
+		}
+	default:
+		select {
+		case c.muster.Work <- wr:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
 // Do performs a Graph API request and unmarshal it's response. If the response
 // is an error, it will be returned as an error, else it will be unmarshalled
-// into the result.
+// into the result. Each request in a batch has its own outcome, a failed
+// request results in an *fbapi.Error for its caller only. The returned
+// *http.Response is built from the request's response in the batch, including
+// its headers, such as ETag or X-App-Usage, when the batch includes them.
 func (c *Client) Do(req *http.Request, result interface{}) (*http.Response, error) {
+	return c.DoContext(context.Background(), req, result)
+}
+
+// DoContext is like Do, but returns the context error as soon as the context
+// is done, whether waiting to enqueue the request or for its batch. A request
+// already enqueued is still sent as part of its batch, only its response is
+// discarded.
+func (c *Client) DoContext(ctx context.Context, req *http.Request, result interface{}) (*http.Response, error) {
 	if err := c.start(); err != nil {
 		return nil, err
 	}
@@ -240,12 +666,22 @@ func (c *Client) Do(req *http.Request, result interface{}) (*http.Response, erro
 	}
 
 	wrc := make(chan *workResponse, 1)
-	c.muster.Work <- &workRequest{Request: breq, Response: wrc}
-	wr := <-wrc
+	if err := c.enqueue(ctx, &workRequest{Request: breq, Response: wrc}); err != nil {
+		return nil, err
+	}
+	var wr *workResponse
+	select {
+	case wr = <-wrc:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
 	if wr.Error != nil {
 		return nil, wr.Error
 	}
 	hres, err := wr.Response.httpResponse()
+	if err != nil {
+		return nil, err
+	}
 	hres.Request = req
 
 	if err := fbapi.UnmarshalResponse(hres, result); err != nil {
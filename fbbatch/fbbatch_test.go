@@ -1,14 +1,19 @@
 package fbbatch
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/url"
+	"regexp"
+	"sort"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/facebookgo/ensure"
 	"github.com/facebookgo/fbapi"
@@ -21,6 +26,23 @@ func (f fTransport) RoundTrip(r *http.Request) (*http.Response, error) {
 	return f(r)
 }
 
+// Returns a copy of the error without the response details, which are only
+// available through methods, so it can be compared to an expected *fbapi.Error.
+func withoutResponse(err error) error {
+	apiErr, ok := err.(*fbapi.Error)
+	if !ok {
+		return err
+	}
+	return &fbapi.Error{
+		Message:    apiErr.Message,
+		Type:       apiErr.Type,
+		Code:       apiErr.Code,
+		Subcode:    apiErr.Subcode,
+		FBTraceID:  apiErr.FBTraceID,
+		RetryAfter: apiErr.RetryAfter,
+	}
+}
+
 type fReader func([]byte) (int, error)
 
 func (f fReader) Read(p []byte) (int, error) { return f(p) }
@@ -60,6 +82,87 @@ func TestNewRequestBodyReadError(t *testing.T) {
 	ensure.True(t, err == givenErr, err)
 }
 
+func TestNewBatchRequest(t *testing.T) {
+	cases := []struct {
+		Method   string
+		Path     string
+		Params   []fbapi.Param
+		Expected *Request
+	}{
+		{
+			Method: "GET",
+			Path:   "me/feed",
+			Params: []fbapi.Param{fbapi.ParamFields("id", "message"), fbapi.ParamLimit(5)},
+			Expected: &Request{
+				Method:      "GET",
+				RelativeURL: "me/feed?fields=id%2Cmessage&limit=5",
+			},
+		},
+		{
+			Method: "GET",
+			Path:   "me/feed?after=MQ",
+			Params: []fbapi.Param{fbapi.ParamLimit(5)},
+			Expected: &Request{
+				Method:      "GET",
+				RelativeURL: "me/feed?after=MQ&limit=5",
+			},
+		},
+		{
+			Method: "GET",
+			Path:   "me",
+			Expected: &Request{
+				Method:      "GET",
+				RelativeURL: "me",
+			},
+		},
+		{
+			Method: "POST",
+			Path:   "me/feed",
+			Params: []fbapi.Param{fbapi.ParamAccessToken("at"), fbapi.ParamFields("id")},
+			Expected: &Request{
+				Method:      "POST",
+				RelativeURL: "me/feed",
+				Body:        "access_token=at&fields=id",
+			},
+		},
+		{
+			Method: "GET",
+			Path:   "/",
+			Params: []fbapi.Param{fbapi.ParamString("ids", "{result=result0:$.data.*.id}"), fbapi.ParamLimit(5)},
+			Expected: &Request{
+				Method:      "GET",
+				RelativeURL: "/?ids={result=result0:$.data.*.id}&limit=5",
+			},
+		},
+		{
+			Method: "POST",
+			Path:   "me/feed",
+			Params: []fbapi.Param{fbapi.ParamString("message", "id {result=post:$.id} & more")},
+			Expected: &Request{
+				Method:      "POST",
+				RelativeURL: "me/feed",
+				Body:        "message=id+{result=post:$.id}+%26+more",
+			},
+		},
+	}
+	for _, c := range cases {
+		actual, err := NewBatchRequest(c.Method, c.Path, c.Params...)
+		ensure.Nil(t, err)
+		ensure.DeepEqual(t, actual, c.Expected)
+	}
+}
+
+type paramWithError struct{}
+
+func (paramWithError) Set(url.Values) error {
+	return errors.New("param error")
+}
+
+func TestNewBatchRequestParamError(t *testing.T) {
+	_, err := NewBatchRequest("GET", "me", paramWithError{})
+	ensure.Err(t, err, regexp.MustCompile("param error"))
+}
+
 func TestHTTPResponse(t *testing.T) {
 	const (
 		code       = http.StatusOK
@@ -79,7 +182,7 @@ func TestHTTPResponse(t *testing.T) {
 	hr, err := br.httpResponse()
 	ensure.Nil(t, err)
 	ensure.DeepEqual(t, hr, &http.Response{
-		Status:        http.StatusText(code),
+		Status:        "200 OK",
 		StatusCode:    code,
 		Proto:         "HTTP/1.1",
 		ProtoMajor:    1,
@@ -125,6 +228,52 @@ func TestBatchDo(t *testing.T) {
 	ensure.DeepEqual(t, actual, given)
 }
 
+func TestBatchDoOmitHeaders(t *testing.T) {
+	b := &Batch{
+		Request:     []*Request{{Method: "GET", RelativeURL: "/me"}},
+		OmitHeaders: true,
+	}
+	c := &fbapi.Client{
+		Transport: fTransport(func(r *http.Request) (*http.Response, error) {
+			ensure.Nil(t, r.ParseForm())
+			ensure.DeepEqual(t, r.PostFormValue("include_headers"), "false")
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(strings.NewReader(`[{"code":200,"body":"{\"id\":\"42\"}"}]`)),
+			}, nil
+		}),
+	}
+	actual, err := BatchDo(c, b)
+	ensure.Nil(t, err)
+	ensure.DeepEqual(t, len(actual), 1)
+	ensure.DeepEqual(t, len(actual[0].Header), 0)
+
+	res, err := actual[0].httpResponse()
+	ensure.Nil(t, err)
+	ensure.DeepEqual(t, res.StatusCode, http.StatusOK)
+	ensure.DeepEqual(t, res.Status, "200 OK")
+	ensure.DeepEqual(t, res.Header, http.Header{})
+	var v struct{ ID string }
+	ensure.Nil(t, fbapi.UnmarshalResponse(res, &v))
+	ensure.DeepEqual(t, v.ID, "42")
+}
+
+func TestBatchDoIncludesHeadersByDefault(t *testing.T) {
+	c := &fbapi.Client{
+		Transport: fTransport(func(r *http.Request) (*http.Response, error) {
+			ensure.Nil(t, r.ParseForm())
+			_, ok := r.PostForm["include_headers"]
+			ensure.False(t, ok)
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(strings.NewReader(`[]`)),
+			}, nil
+		}),
+	}
+	_, err := BatchDo(c, &Batch{})
+	ensure.Nil(t, err)
+}
+
 func TestBatchDoTransportError(t *testing.T) {
 	givenErr := errors.New("")
 	c := &fbapi.Client{
@@ -165,6 +314,511 @@ func TestClientDo(t *testing.T) {
 	ensure.DeepEqual(t, actual, given)
 }
 
+func TestClientDoResponseHeaders(t *testing.T) {
+	const body = `{"id":"42"}`
+	wrapped := []map[string]interface{}{
+		{
+			"code": http.StatusCreated,
+			"headers": []Header{
+				{Name: "ETag", Value: `"abc"`},
+				{Name: "X-App-Usage", Value: `{"call_count":5}`},
+			},
+			"body": body,
+		},
+	}
+	c := &Client{
+		Client: &fbapi.Client{
+			Transport: fTransport(func(r *http.Request) (*http.Response, error) {
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       ioutil.NopCloser(jsonpipe.Encode(wrapped)),
+				}, nil
+			}),
+		},
+	}
+	req := &http.Request{Method: "POST", URL: &url.URL{Path: "me/feed"}}
+	res, err := c.Do(req, nil)
+	ensure.Nil(t, err)
+	ensure.DeepEqual(t, res.StatusCode, http.StatusCreated)
+	ensure.DeepEqual(t, res.Status, "201 Created")
+	ensure.DeepEqual(t, res.ContentLength, int64(len(body)))
+	ensure.DeepEqual(t, res.Header.Get("ETag"), `"abc"`)
+	ensure.DeepEqual(t, res.Header.Get("X-App-Usage"), `{"call_count":5}`)
+	ensure.True(t, res.Request == req)
+}
+
 func TestStopClient(t *testing.T) {
 	ensure.Nil(t, (&Client{Client: &fbapi.Client{}}).Stop())
 }
+
+// Returns a Client whose pending work queue has the given capacity and is not
+// being processed, as if the background worker was blocked.
+func newBlockedClient(capacity int, policy OverloadPolicy) *Client {
+	c := &Client{Client: &fbapi.Client{}, Overload: policy}
+	c.startOnce.Do(func() {})
+	c.muster.Work = make(chan interface{}, capacity)
+	return c
+}
+
+func TestOverloadReject(t *testing.T) {
+	c := newBlockedClient(1, Reject)
+	go c.Do(&http.Request{Method: "GET", URL: &url.URL{Path: "1"}}, nil)
+	// wait for the first request to occupy the queue
+	for len(c.muster.Work) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	_, err := c.Do(&http.Request{Method: "GET", URL: &url.URL{Path: "2"}}, nil)
+	ensure.True(t, err == ErrOverloaded, err)
+
+	wr := (<-c.muster.Work).(*workRequest)
+	wr.Response <- &workResponse{Error: errors.New("")}
+}
+
+func TestOverloadDropOldest(t *testing.T) {
+	c := newBlockedClient(1, DropOldest)
+	firstErr := make(chan error)
+	go func() {
+		_, err := c.Do(&http.Request{Method: "GET", URL: &url.URL{Path: "1"}}, nil)
+		firstErr <- err
+	}()
+	for len(c.muster.Work) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	secondErr := make(chan error)
+	go func() {
+		_, err := c.Do(&http.Request{Method: "GET", URL: &url.URL{Path: "2"}}, nil)
+		secondErr <- err
+	}()
+	ensure.True(t, <-firstErr == ErrOverloaded)
+
+	wr := (<-c.muster.Work).(*workRequest)
+	ensure.DeepEqual(t, wr.Request.RelativeURL, "2")
+	wr.Response <- &workResponse{Response: &Response{Code: http.StatusOK, Body: "{}"}}
+	ensure.Nil(t, <-secondErr)
+}
+
+func TestClientDoContextCanceledWaitingForBatch(t *testing.T) {
+	c := newBlockedClient(1, Block)
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := make(chan error)
+	go func() {
+		_, err := c.DoContext(ctx, &http.Request{Method: "GET", URL: &url.URL{Path: "1"}}, nil)
+		errc <- err
+	}()
+	for len(c.muster.Work) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	ensure.DeepEqual(t, <-errc, context.Canceled)
+
+	// the batch can still deliver the abandoned response without blocking
+	wr := (<-c.muster.Work).(*workRequest)
+	wr.Response <- &workResponse{Response: &Response{Code: http.StatusOK, Body: "{}"}}
+}
+
+func TestClientDoContextCanceledWaitingToEnqueue(t *testing.T) {
+	c := newBlockedClient(0, Block)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	_, err := c.DoContext(ctx, &http.Request{Method: "GET", URL: &url.URL{Path: "1"}}, nil)
+	ensure.DeepEqual(t, err, context.DeadlineExceeded)
+}
+
+func TestClientDoMixedOutcomes(t *testing.T) {
+	c := &Client{
+		MaxBatchSize: 3,
+		BatchTimeout: time.Hour,
+		Client: &fbapi.Client{
+			Transport: fTransport(func(r *http.Request) (*http.Response, error) {
+				ensure.Nil(t, r.ParseForm())
+				var requests []*Request
+				ensure.Nil(t, json.Unmarshal([]byte(r.PostFormValue("batch")), &requests))
+				var responses []*Response
+				for _, req := range requests {
+					switch req.RelativeURL {
+					case "ok":
+						responses = append(responses, &Response{
+							Code: http.StatusOK,
+							Body: `{"id":"42"}`,
+						})
+					case "fail":
+						responses = append(responses, &Response{
+							Code: http.StatusForbidden,
+							Body: `{"error":{"code":200,"message":"Permissions error"}}`,
+						})
+					default:
+						responses = append(responses, nil)
+					}
+				}
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       ioutil.NopCloser(jsonpipe.Encode(responses)),
+				}, nil
+			}),
+		},
+	}
+	defer c.Stop()
+
+	type outcome struct {
+		Path   string
+		Result map[string]string
+		Err    error
+	}
+	outcomes := make(chan outcome, 3)
+	for _, path := range []string{"ok", "fail", "null"} {
+		go func(path string) {
+			var result map[string]string
+			_, err := c.Do(&http.Request{Method: "GET", URL: &url.URL{Path: path}}, &result)
+			outcomes <- outcome{Path: path, Result: result, Err: err}
+		}(path)
+	}
+	for i := 0; i < 3; i++ {
+		o := <-outcomes
+		switch o.Path {
+		case "ok":
+			ensure.Nil(t, o.Err)
+			ensure.DeepEqual(t, o.Result, map[string]string{"id": "42"})
+		case "fail":
+			ensure.DeepEqual(t, withoutResponse(o.Err), &fbapi.Error{Code: 200, Message: "Permissions error"})
+			ensure.DeepEqual(t, o.Err.(*fbapi.Error).HTTPStatus(), http.StatusForbidden)
+			ensure.True(t, o.Result == nil)
+		case "null":
+			ensure.True(t, o.Err == errMissingResponse, o.Err)
+		}
+	}
+}
+
+func TestBatchDoSplitsOversizedBatch(t *testing.T) {
+	const total = 120
+	var calls []int
+	c := &fbapi.Client{
+		Transport: fTransport(func(r *http.Request) (*http.Response, error) {
+			ensure.Nil(t, r.ParseForm())
+			var requests []*Request
+			ensure.Nil(t, json.Unmarshal([]byte(r.PostFormValue("batch")), &requests))
+			calls = append(calls, len(requests))
+			if len(calls) == 2 {
+				return nil, errors.New("chunk failed")
+			}
+			responses := make([]*Response, len(requests))
+			for i, req := range requests {
+				responses[i] = &Response{Code: http.StatusOK, Body: req.RelativeURL}
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(jsonpipe.Encode(responses)),
+			}, nil
+		}),
+	}
+	b := &Batch{}
+	for i := 0; i < total; i++ {
+		b.Request = append(b.Request, &Request{Method: "GET", RelativeURL: fmt.Sprint(i)})
+	}
+	res, err := BatchDo(c, b)
+	ensure.DeepEqual(t, calls, []int{50, 50, 20})
+	ensure.DeepEqual(t, len(res), total)
+	for i, r := range res {
+		if i >= 50 && i < 100 {
+			ensure.True(t, r == nil, i)
+		} else {
+			ensure.DeepEqual(t, r.Body, fmt.Sprint(i))
+		}
+	}
+	errs, ok := err.(ChunkErrors)
+	ensure.True(t, ok, err)
+	ensure.DeepEqual(t, len(errs), 1)
+	ensure.DeepEqual(t, errs[0].Start, 50)
+	ensure.DeepEqual(t, errs[0].End, 100)
+	ensure.DeepEqual(t, err.Error(), "fbbatch: requests 50 to 99 failed: chunk failed")
+	ensure.True(t, requestError(err, 75) == errs[0])
+}
+
+func TestPerRequestAccessToken(t *testing.T) {
+	c := &fbapi.Client{
+		Transport: fTransport(func(r *http.Request) (*http.Response, error) {
+			ensure.Nil(t, r.ParseForm())
+			ensure.DeepEqual(t, r.PostFormValue("access_token"), "batch")
+			var requests []*Request
+			ensure.Nil(t, json.Unmarshal([]byte(r.PostFormValue("batch")), &requests))
+			ensure.DeepEqual(t, requests[0].RelativeURL, "/me?access_token=user1")
+			ensure.DeepEqual(t, requests[1].RelativeURL, "/me/feed?limit=5&access_token=user%2B2")
+			ensure.DeepEqual(t, requests[2].RelativeURL, "/me")
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(strings.NewReader(`[]`)),
+			}, nil
+		}),
+	}
+	b := &Batch{
+		AccessToken: "batch",
+		Request: []*Request{
+			{Method: "GET", RelativeURL: "/me", AccessToken: "user1"},
+			{Method: "GET", RelativeURL: "/me/feed?limit=5", AccessToken: "user+2"},
+			{Method: "GET", RelativeURL: "/me"},
+		},
+	}
+	_, err := BatchDo(c, b)
+	ensure.Nil(t, err)
+}
+
+func TestRequestRedactsAccessToken(t *testing.T) {
+	r := &Request{RelativeURL: "/me", AccessToken: "secret"}
+	for _, format := range []string{"%v", "%+v", "%#v", "%s"} {
+		s := fmt.Sprintf(format, r)
+		ensure.False(t, strings.Contains(s, "secret"), s)
+		ensure.StringContains(t, s, "REDACTED")
+	}
+	ensure.DeepEqual(t, r.AccessToken, "secret")
+}
+
+func TestSubmissionOrder(t *testing.T) {
+	batches := make(chan []string, 2)
+	c := &Client{
+		MaxBatchSize: 3,
+		BatchTimeout: time.Hour,
+		Client: &fbapi.Client{
+			Transport: fTransport(func(r *http.Request) (*http.Response, error) {
+				ensure.Nil(t, r.ParseForm())
+				var requests []*Request
+				ensure.Nil(t, json.Unmarshal([]byte(r.PostFormValue("batch")), &requests))
+				var urls []string
+				responses := make([]*Response, len(requests))
+				for i, req := range requests {
+					urls = append(urls, req.RelativeURL)
+					responses[i] = &Response{Code: http.StatusOK, Body: "{}"}
+				}
+				batches <- urls
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       ioutil.NopCloser(jsonpipe.Encode(responses)),
+				}, nil
+			}),
+		},
+	}
+	ensure.Nil(t, c.start())
+
+	var responses []chan *workResponse
+	for i := 0; i < 6; i++ {
+		wrc := make(chan *workResponse, 1)
+		responses = append(responses, wrc)
+		wr := &workRequest{Request: &Request{Method: "GET", RelativeURL: fmt.Sprint(i)}, Response: wrc}
+		ensure.Nil(t, c.enqueue(context.Background(), wr))
+	}
+	// batches are sent concurrently, so they may be received in any order
+	received := [][]string{<-batches, <-batches}
+	sort.Slice(received, func(i, j int) bool { return received[i][0] < received[j][0] })
+	ensure.DeepEqual(t, received, [][]string{{"0", "1", "2"}, {"3", "4", "5"}})
+	for _, wrc := range responses {
+		ensure.Nil(t, (<-wrc).Error)
+	}
+	ensure.Nil(t, c.Stop())
+}
+
+func TestBatchDoAttachedFiles(t *testing.T) {
+	c := &fbapi.Client{
+		Transport: fTransport(func(r *http.Request) (*http.Response, error) {
+			ensure.StringContains(t, r.Header.Get("Content-Type"), "multipart/form-data; boundary=")
+			ensure.Nil(t, r.ParseMultipartForm(1024))
+			ensure.DeepEqual(t, r.FormValue("access_token"), "at")
+			ensure.DeepEqual(t, r.FormValue("batch"), `[`+
+				`{"method":"POST","relative_url":"me/photos","body":"message=hi","attached_files":"photo1"},`+
+				`{"method":"GET","relative_url":"me"}]`)
+			f, header, err := r.FormFile("photo1")
+			ensure.Nil(t, err)
+			ensure.DeepEqual(t, header.Filename, "cat.jpg")
+			b, err := ioutil.ReadAll(f)
+			ensure.Nil(t, err)
+			ensure.DeepEqual(t, string(b), "image")
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(strings.NewReader(`[{"code":200},{"code":200}]`)),
+			}, nil
+		}),
+	}
+	b := &Batch{
+		AccessToken: "at",
+		Request: []*Request{
+			{
+				Method:      "POST",
+				RelativeURL: "me/photos",
+				Body:        "message=hi",
+				Files:       []*File{{Name: "photo1", Filename: "cat.jpg", Body: strings.NewReader("image")}},
+			},
+			{Method: "GET", RelativeURL: "me"},
+		},
+	}
+	res, err := BatchDo(c, b)
+	ensure.Nil(t, err)
+	ensure.DeepEqual(t, len(res), 2)
+}
+
+// Returns a Client whose Batch calls get the given responses in order, and a
+// pointer to the number of calls made.
+func newSequenceClient(t *testing.T, retries int, responses ...*http.Response) (*Client, *int) {
+	var calls int
+	return &Client{
+		BatchRetries:    retries,
+		BatchRetryDelay: time.Millisecond,
+		Client: &fbapi.Client{
+			Transport: fTransport(func(r *http.Request) (*http.Response, error) {
+				if calls >= len(responses) {
+					t.Fatalf("unexpected batch call %d", calls+1)
+				}
+				res := responses[calls]
+				calls++
+				return res, nil
+			}),
+		},
+	}, &calls
+}
+
+func batchResponse(code int, body string, header http.Header) *http.Response {
+	return &http.Response{
+		StatusCode: code,
+		Header:     header,
+		Body:       ioutil.NopCloser(strings.NewReader(body)),
+	}
+}
+
+func TestBatchRetryRateLimited(t *testing.T) {
+	c, calls := newSequenceClient(t, 2,
+		batchResponse(http.StatusBadRequest, `{"error":{"code":4}}`, nil),
+		batchResponse(http.StatusTooManyRequests, `slow down`, http.Header{"Retry-After": []string{"0"}}),
+		batchResponse(http.StatusOK, `[{"code":403,"body":"{\"error\":{\"code\":200}}"}]`, nil),
+	)
+	res, err := c.batchDo(context.Background(), &Batch{Request: []*Request{{Method: "GET", RelativeURL: "me"}}})
+	ensure.Nil(t, err)
+	ensure.DeepEqual(t, *calls, 3)
+	ensure.DeepEqual(t, res, []*Response{{Code: http.StatusForbidden, Body: `{"error":{"code":200}}`}})
+}
+
+func TestBatchRetryAfterHeader(t *testing.T) {
+	c, calls := newSequenceClient(t, 1,
+		batchResponse(http.StatusTooManyRequests, `slow down`, http.Header{"Retry-After": []string{"0"}}),
+		batchResponse(http.StatusOK, `[]`, nil),
+	)
+	// the backoff delay would time out the test if the header was ignored
+	c.BatchRetryDelay = time.Hour
+	_, err := c.batchDo(context.Background(), &Batch{})
+	ensure.Nil(t, err)
+	ensure.DeepEqual(t, *calls, 2)
+}
+
+func TestBatchRetryExhausted(t *testing.T) {
+	c, calls := newSequenceClient(t, 1,
+		batchResponse(http.StatusServiceUnavailable, `{"error":{"code":2,"message":"first"}}`, nil),
+		batchResponse(http.StatusServiceUnavailable, `{"error":{"code":2,"message":"last"}}`, nil),
+	)
+	_, err := c.batchDo(context.Background(), &Batch{})
+	ensure.DeepEqual(t, withoutResponse(err), &fbapi.Error{Code: 2, Message: "last"})
+	ensure.DeepEqual(t, *calls, 2)
+}
+
+func TestBatchRetryPermanentError(t *testing.T) {
+	c, calls := newSequenceClient(t, 2,
+		batchResponse(http.StatusBadRequest, `{"error":{"code":100}}`, nil),
+	)
+	_, err := c.batchDo(context.Background(), &Batch{})
+	ensure.DeepEqual(t, withoutResponse(err), &fbapi.Error{Code: 100})
+	ensure.DeepEqual(t, *calls, 1)
+}
+
+func TestBatchRetryDisabledByDefault(t *testing.T) {
+	c, calls := newSequenceClient(t, 0,
+		batchResponse(http.StatusBadRequest, `{"error":{"code":4}}`, nil),
+	)
+	_, err := c.batchDo(context.Background(), &Batch{})
+	ensure.DeepEqual(t, withoutResponse(err), &fbapi.Error{Code: 4})
+	ensure.DeepEqual(t, *calls, 1)
+}
+
+func TestBatchRetryNotWithFiles(t *testing.T) {
+	c, calls := newSequenceClient(t, 2,
+		batchResponse(http.StatusBadRequest, `{"error":{"code":4}}`, nil),
+	)
+	_, err := c.batchDo(context.Background(), &Batch{Request: []*Request{{
+		Method:      "POST",
+		RelativeURL: "me/photos",
+		Files:       []*File{{Name: "photo", Filename: "a.jpg", Body: strings.NewReader("jpeg")}},
+	}}})
+	ensure.DeepEqual(t, withoutResponse(err), &fbapi.Error{Code: 4})
+	ensure.DeepEqual(t, *calls, 1)
+}
+
+type recordingStats struct {
+	mu       sync.Mutex
+	counts   map[string]int
+	recorded map[string][]float64
+}
+
+func (s *recordingStats) Inc(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.counts[name]++
+}
+
+func (s *recordingStats) Record(name string, value float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.recorded[name] = append(s.recorded[name], value)
+}
+
+func TestClientStats(t *testing.T) {
+	stats := &recordingStats{
+		counts:   make(map[string]int),
+		recorded: make(map[string][]float64),
+	}
+	c := &Client{
+		Stats:        stats,
+		MaxBatchSize: 3,
+		BatchTimeout: time.Hour,
+		Client: &fbapi.Client{
+			Transport: fTransport(func(r *http.Request) (*http.Response, error) {
+				return batchResponse(http.StatusOK, `[
+					{"code": 200, "body": "{}"},
+					{"code": 400, "body": "{\"error\":{\"code\":100}}"},
+					null
+				]`, nil), nil
+			}),
+		},
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.Do(&http.Request{Method: "GET", URL: &url.URL{Path: "me"}}, nil)
+		}()
+	}
+	wg.Wait()
+	ensure.Nil(t, c.Stop())
+
+	ensure.DeepEqual(t, stats.recorded["batch.size"], []float64{3})
+	ensure.DeepEqual(t, stats.recorded["batch.request_errors"], []float64{2})
+	ensure.DeepEqual(t, len(stats.recorded["batch.time"]), 1)
+	ensure.DeepEqual(t, stats.counts, map[string]int{})
+}
+
+func TestClientStatsBatchError(t *testing.T) {
+	stats := &recordingStats{
+		counts:   make(map[string]int),
+		recorded: make(map[string][]float64),
+	}
+	c := &Client{
+		Stats:        stats,
+		BatchTimeout: time.Millisecond,
+		Client: &fbapi.Client{
+			Transport: fTransport(func(r *http.Request) (*http.Response, error) {
+				return batchResponse(http.StatusBadRequest, `{"error":{"code":4}}`, nil), nil
+			}),
+		},
+	}
+	_, err := c.Do(&http.Request{Method: "GET", URL: &url.URL{Path: "me"}}, nil)
+	ensure.NotNil(t, err)
+	ensure.Nil(t, c.Stop())
+
+	ensure.DeepEqual(t, stats.recorded["batch.size"], []float64{1})
+	ensure.DeepEqual(t, stats.recorded["batch.request_errors"], []float64{1})
+	ensure.DeepEqual(t, stats.counts, map[string]int{"batch.error": 1})
+}
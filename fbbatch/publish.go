@@ -0,0 +1,71 @@
+package fbbatch
+
+import (
+	"context"
+
+	"github.com/facebookgo/fbapi"
+)
+
+// PublishItem is an object to publish by POSTing the Params to the Path, for
+// example the message of a post to /{page-id}/feed.
+type PublishItem struct {
+	Path   string
+	Params []fbapi.Param
+}
+
+// PublishResult is the outcome of publishing a PublishItem, either the ID of
+// the created object or the error.
+type PublishResult struct {
+	ID  string
+	Err error
+}
+
+// PublishAll publishes the items using Batch calls, returning the results in
+// the same order so the failed items can be retried. An error is returned only
+// if none of the items could be published because the requests couldn't be
+// made or all the Batch calls failed, the error of the call if there was one
+// or ChunkErrors otherwise. If only some of the calls fail, the affected items
+// have the corresponding ChunkError.
+func (c *Client) PublishAll(ctx context.Context, items []PublishItem) ([]PublishResult, error) {
+	b := &Batch{
+		AccessToken: c.AccessToken,
+		AppID:       c.AppID,
+		Request:     make([]*Request, len(items)),
+	}
+	for i, item := range items {
+		req, err := NewBatchRequest("POST", item.Path, item.Params...)
+		if err != nil {
+			return nil, err
+		}
+		b.Request[i] = req
+	}
+
+	res, err := c.batchDo(ctx, b)
+	if errs, ok := err.(ChunkErrors); err != nil && (!ok || errs.all(len(items))) {
+		return nil, err
+	}
+
+	created := make([]struct {
+		ID string `json:"id"`
+	}, len(items))
+	decoded := make([]interface{}, len(items))
+	for i := range created {
+		decoded[i] = &created[i]
+	}
+	errs := Decode(res, decoded)
+
+	results := make([]PublishResult, len(items))
+	for i := range results {
+		switch {
+		case i >= len(errs):
+			results[i].Err = errMissingResponse
+		case res[i] == nil && err != nil:
+			results[i].Err = requestError(err, i)
+		case errs[i] != nil:
+			results[i].Err = errs[i]
+		default:
+			results[i].ID = created[i].ID
+		}
+	}
+	return results, nil
+}
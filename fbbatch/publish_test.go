@@ -0,0 +1,127 @@
+package fbbatch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/facebookgo/ensure"
+	"github.com/facebookgo/fbapi"
+	"github.com/facebookgo/jsonpipe"
+)
+
+func TestPublishAll(t *testing.T) {
+	c := &Client{
+		AccessToken: "at",
+		Client: &fbapi.Client{
+			Transport: fTransport(func(r *http.Request) (*http.Response, error) {
+				ensure.Nil(t, r.ParseForm())
+				ensure.DeepEqual(t, r.PostFormValue("access_token"), "at")
+				var requests []*Request
+				ensure.Nil(t, json.Unmarshal([]byte(r.PostFormValue("batch")), &requests))
+				ensure.DeepEqual(t, len(requests), 3)
+				for _, req := range requests {
+					ensure.DeepEqual(t, req.Method, "POST")
+					ensure.DeepEqual(t, req.RelativeURL, "42/feed")
+				}
+				body, err := url.ParseQuery(requests[1].Body)
+				ensure.Nil(t, err)
+				ensure.DeepEqual(t, body.Get("message"), "two")
+
+				responses := []*Response{
+					{Code: http.StatusOK, Body: `{"id":"42_1"}`},
+					{Code: http.StatusBadRequest, Body: `{"error":{"code":368}}`},
+					{Code: http.StatusOK, Body: `{"id":"42_3"}`},
+				}
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       ioutil.NopCloser(jsonpipe.Encode(responses)),
+				}, nil
+			}),
+		},
+	}
+	var items []PublishItem
+	for _, message := range []string{"one", "two", "three"} {
+		items = append(items, PublishItem{
+			Path:   "42/feed",
+			Params: []fbapi.Param{fbapi.ParamString("message", message)},
+		})
+	}
+	results, err := c.PublishAll(context.Background(), items)
+	ensure.Nil(t, err)
+	for i := range results {
+		results[i].Err = withoutResponse(results[i].Err)
+	}
+	ensure.DeepEqual(t, results, []PublishResult{
+		{ID: "42_1"},
+		{Err: &fbapi.Error{Code: 368}},
+		{ID: "42_3"},
+	})
+}
+
+func TestPublishAllBatchError(t *testing.T) {
+	givenErr := errors.New("")
+	c := &Client{
+		Client: &fbapi.Client{
+			Transport: fTransport(func(r *http.Request) (*http.Response, error) {
+				return nil, givenErr
+			}),
+		},
+	}
+	results, err := c.PublishAll(context.Background(), []PublishItem{{Path: "42/feed"}})
+	ensure.True(t, err == givenErr, err)
+	ensure.True(t, results == nil)
+}
+
+func TestPublishAllChunks(t *testing.T) {
+	givenErr := errors.New("batch failed")
+	var calls int
+	c := &Client{
+		Client: &fbapi.Client{
+			Transport: fTransport(func(r *http.Request) (*http.Response, error) {
+				calls++
+				if calls != 2 {
+					return nil, givenErr
+				}
+				responses := make([]*Response, 10)
+				for i := range responses {
+					responses[i] = &Response{Code: http.StatusOK, Body: `{"id":"42_1"}`}
+				}
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       ioutil.NopCloser(jsonpipe.Encode(responses)),
+				}, nil
+			}),
+		},
+	}
+	items := make([]PublishItem, maxBatchRequests+10)
+	for i := range items {
+		items[i].Path = "42/feed"
+	}
+	results, err := c.PublishAll(context.Background(), items)
+	ensure.Nil(t, err)
+	ensure.DeepEqual(t, results[0].Err, &ChunkError{Start: 0, End: maxBatchRequests, Err: givenErr})
+	ensure.DeepEqual(t, results[maxBatchRequests].ID, "42_1")
+
+	// both calls fail
+	calls = 2
+	results, err = c.PublishAll(context.Background(), items)
+	ensure.True(t, results == nil)
+	ensure.DeepEqual(t, err, ChunkErrors{
+		{Start: 0, End: maxBatchRequests, Err: givenErr},
+		{Start: maxBatchRequests, End: len(items), Err: givenErr},
+	})
+}
+
+func TestPublishAllParamError(t *testing.T) {
+	c := &Client{Client: &fbapi.Client{}}
+	_, err := c.PublishAll(context.Background(), []PublishItem{{
+		Path:   "42/feed",
+		Params: []fbapi.Param{paramWithError{}},
+	}})
+	ensure.NotNil(t, err)
+}
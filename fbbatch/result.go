@@ -0,0 +1,128 @@
+package fbbatch
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+)
+
+var (
+	errRequestNotInBatch = errors.New("fbbatch: request is not in the batch")
+	errNameInUse         = errors.New("fbbatch: request name is already used in the batch")
+)
+
+// Matches the name in the result references of a relative URL or body.
+var resultReference = regexp.MustCompile(`\{result=([^:}]+):`)
+
+// Matches query escaped result references.
+var escapedResultReference = regexp.MustCompile(`(?i)%7Bresult%3D.*?%7D`)
+
+// Returns the query encoded s with the result references unescaped, as the API
+// only replaces them as is.
+func unescapeResults(s string) string {
+	return escapedResultReference.ReplaceAllStringFunc(s, func(ref string) string {
+		if unescaped, err := url.QueryUnescape(ref); err == nil {
+			return unescaped
+		}
+		return ref
+	})
+}
+
+// UnknownResultError is returned when a request references the result of a
+// request name not defined before it in the same batch.
+type UnknownResultError struct {
+	Name string
+}
+
+func (e *UnknownResultError) Error() string {
+	return fmt.Sprintf("fbbatch: reference to unknown request name %q", e.Name)
+}
+
+// Add appends r to the batch, returning an error if its Name is already used
+// by another request in the batch.
+func (b *Batch) Add(r *Request) error {
+	if r.Name != "" {
+		for _, req := range b.Request {
+			if req.Name == r.Name {
+				return errNameInUse
+			}
+		}
+	}
+	b.Request = append(b.Request, r)
+	return nil
+}
+
+// Result returns a reference to the result of r, a request in the batch, to be
+// used in the relative URL or body of subsequent requests in the same batch.
+// The path is a JSONPath expression selecting the value from the response of
+// r, for example "$.data.*.id". If r doesn't have a Name one is assigned.
+// Names must be unique within the batch, so an error is returned if another
+// request already uses the name of r, or the one that would be assigned.
+//
+// The reference is replaced by the API, so it must be included as is and not
+// be escaped. Note references across the chunks of batches split by BatchDo
+// are not supported, and fail the chunk with an UnknownResultError.
+func (b *Batch) Result(r *Request, path string) (string, error) {
+	name, err := b.name(r)
+	if err != nil {
+		return "", err
+	}
+	r.Name = name
+	return fmt.Sprintf("{result=%s:%s}", r.Name, path), nil
+}
+
+// ResultField returns a reference to a top level field of the result of r,
+// such as the "id" of a created object. See Result.
+func (b *Batch) ResultField(r *Request, field string) (string, error) {
+	return b.Result(r, "$."+field)
+}
+
+// Returns the name of r, or the one to assign to it, checking r is in the
+// batch and that no other request uses the name.
+func (b *Batch) name(r *Request) (string, error) {
+	i := -1
+	for j, req := range b.Request {
+		if req == r {
+			i = j
+			break
+		}
+	}
+	if i < 0 {
+		return "", errRequestNotInBatch
+	}
+	name := r.Name
+	if name == "" {
+		name = fmt.Sprintf("result%d", i)
+	}
+	for _, req := range b.Request {
+		if req != r && req.Name == name {
+			return "", errNameInUse
+		}
+	}
+	return name, nil
+}
+
+// Check the names of the requests are unique, and the result references only
+// use the names of requests before them.
+func (b *Batch) checkNames() error {
+	names := make(map[string]bool, len(b.Request))
+	for _, r := range b.Request {
+		// escaped references aren't replaced by the API, but are checked
+		// anyway as they are likely meant to be
+		for _, s := range []string{r.RelativeURL, r.Body, unescapeResults(r.RelativeURL), unescapeResults(r.Body)} {
+			for _, m := range resultReference.FindAllStringSubmatch(s, -1) {
+				if !names[m[1]] {
+					return &UnknownResultError{Name: m[1]}
+				}
+			}
+		}
+		if r.Name != "" {
+			if names[r.Name] {
+				return errNameInUse
+			}
+			names[r.Name] = true
+		}
+	}
+	return nil
+}
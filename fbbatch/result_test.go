@@ -0,0 +1,140 @@
+package fbbatch
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/facebookgo/ensure"
+	"github.com/facebookgo/fbapi"
+)
+
+func TestBatchResult(t *testing.T) {
+	me := &Request{Method: "GET", RelativeURL: "/me/friends?limit=5"}
+	b := &Batch{Request: []*Request{me}}
+	ref, err := b.Result(me, "$.data.*.id")
+	ensure.Nil(t, err)
+	ensure.DeepEqual(t, ref, "{result=result0:$.data.*.id}")
+	ensure.DeepEqual(t, me.Name, "result0")
+
+	b.Request = append(b.Request, &Request{Method: "GET", RelativeURL: "/?ids=" + ref})
+	j, err := json.Marshal(b.Request)
+	ensure.Nil(t, err)
+	ensure.DeepEqual(t, string(j), `[`+
+		`{"name":"result0","method":"GET","relative_url":"/me/friends?limit=5"},`+
+		`{"method":"GET","relative_url":"/?ids={result=result0:$.data.*.id}"}]`)
+}
+
+func TestBatchResultKeepsName(t *testing.T) {
+	r := &Request{Name: "friends"}
+	b := &Batch{Request: []*Request{r}}
+	ref, err := b.Result(r, "$.data.*.id")
+	ensure.Nil(t, err)
+	ensure.DeepEqual(t, ref, "{result=friends:$.data.*.id}")
+
+	_, err = (&Batch{}).Result(r, "$.data.*.id")
+	ensure.DeepEqual(t, err, errRequestNotInBatch)
+}
+
+func TestBatchAdd(t *testing.T) {
+	b := &Batch{}
+	ensure.Nil(t, b.Add(&Request{Name: "me"}))
+	ensure.Nil(t, b.Add(&Request{}))
+	ensure.Nil(t, b.Add(&Request{}))
+	ensure.DeepEqual(t, b.Add(&Request{Name: "me"}), errNameInUse)
+	ensure.DeepEqual(t, len(b.Request), 3)
+}
+
+func TestBatchCheckNames(t *testing.T) {
+	cases := []struct {
+		Request []*Request
+		Err     error
+	}{
+		{
+			Request: []*Request{
+				{Name: "me", RelativeURL: "/me"},
+				{RelativeURL: "/{result=me:$.id}/feed", Body: "message={result=me:$.name}"},
+			},
+		},
+		{
+			Request: []*Request{
+				{RelativeURL: "/?ids={result=friends:$.data.*.id}"},
+			},
+			Err: &UnknownResultError{Name: "friends"},
+		},
+		{
+			Request: []*Request{
+				{RelativeURL: "/?ids={result=later:$.id}"},
+				{Name: "later", RelativeURL: "/me"},
+			},
+			Err: &UnknownResultError{Name: "later"},
+		},
+		{
+			Request: []*Request{{Name: "me"}, {Name: "me"}},
+			Err:     errNameInUse,
+		},
+		{
+			Request: []*Request{
+				{RelativeURL: "/?ids=%7Bresult%3Dnope%3A%24.id%7D"},
+			},
+			Err: &UnknownResultError{Name: "nope"},
+		},
+		{
+			Request: []*Request{
+				{Name: "me", RelativeURL: "/me"},
+				{RelativeURL: "/?ids=%7Bresult%3Dme%3A%24.id%7D"},
+			},
+		},
+	}
+	for _, c := range cases {
+		ensure.DeepEqual(t, (&Batch{Request: c.Request}).checkNames(), c.Err)
+	}
+}
+
+func TestBatchDoUnknownResult(t *testing.T) {
+	c := &fbapi.Client{
+		Transport: fTransport(func(r *http.Request) (*http.Response, error) {
+			panic("not reached")
+		}),
+	}
+	_, err := BatchDo(c, &Batch{Request: []*Request{{RelativeURL: "/{result=me:$.id}"}}})
+	ensure.DeepEqual(t, err, &UnknownResultError{Name: "me"})
+}
+
+func TestBatchResultNotInBatch(t *testing.T) {
+	b := &Batch{Request: []*Request{{}}}
+	_, err := b.Result(&Request{}, "$.id")
+	ensure.DeepEqual(t, err, errRequestNotInBatch)
+}
+
+func TestBatchResultField(t *testing.T) {
+	post := &Request{Method: "POST", RelativeURL: "/me/feed", Body: "message=hi"}
+	b := &Batch{Request: []*Request{{Method: "GET", RelativeURL: "/me"}, post}}
+	ref, err := b.ResultField(post, "id")
+	ensure.Nil(t, err)
+	ensure.DeepEqual(t, ref, "{result=result1:$.id}")
+	ensure.DeepEqual(t, post.Name, "result1")
+
+	ref, err = b.ResultField(post, "id")
+	ensure.Nil(t, err)
+	ensure.DeepEqual(t, ref, "{result=result1:$.id}")
+}
+
+func TestBatchResultNameInUse(t *testing.T) {
+	r := &Request{RelativeURL: "/me"}
+	b := &Batch{Request: []*Request{{Name: "result1"}, r}}
+	_, err := b.Result(r, "$.id")
+	ensure.DeepEqual(t, err, errNameInUse)
+	ensure.DeepEqual(t, r.Name, "")
+
+	r.Name = "result1"
+	_, err = b.Result(r, "$.id")
+	ensure.DeepEqual(t, err, errNameInUse)
+}
+
+func TestOmitResponseOnSuccess(t *testing.T) {
+	omit := false
+	j, err := json.Marshal(&Request{RelativeURL: "/me", OmitResponseOnSuccess: &omit})
+	ensure.Nil(t, err)
+	ensure.DeepEqual(t, string(j), `{"relative_url":"/me","omit_response_on_success":false}`)
+}
@@ -0,0 +1,278 @@
+package fbapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+var jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+
+// Field describes a field to request, for use with ParamFields via its String
+// method. Connections can be expanded by specifying nested Fields, and
+// modified using a Limit and Order. For example:
+//
+//	fbapi.ParamFields("id", fbapi.Field{
+//		Name:   "comments",
+//		Limit:  10,
+//		Order:  "reverse_chronological",
+//		Fields: []fbapi.Field{{Name: "from"}, {Name: "message"}},
+//	}.String())
+//
+// Requests id,comments.limit(10).order(reverse_chronological){from,message}.
+type Field struct {
+	Name string
+
+	// The number of items to include for connections, not sent if 0.
+	Limit uint64
+
+	// The order of items for connections, such as "chronological" or
+	// "reverse_chronological".
+	Order string
+
+	// The fields to include for the items.
+	Fields []Field
+}
+
+func (f Field) String() string {
+	var b bytes.Buffer
+	f.write(&b)
+	return b.String()
+}
+
+func (f Field) write(b *bytes.Buffer) {
+	b.WriteString(f.Name)
+	if f.Limit != 0 {
+		b.WriteString(".limit(")
+		b.WriteString(strconv.FormatUint(f.Limit, 10))
+		b.WriteString(")")
+	}
+	if f.Order != "" {
+		b.WriteString(".order(")
+		b.WriteString(f.Order)
+		b.WriteString(")")
+	}
+	if len(f.Fields) != 0 {
+		b.WriteString("{")
+		for i, nested := range f.Fields {
+			if i != 0 {
+				b.WriteString(",")
+			}
+			nested.write(b)
+		}
+		b.WriteString("}")
+	}
+}
+
+type paramFieldsOf struct {
+	v interface{}
+}
+
+func (p paramFieldsOf) Set(values url.Values) error {
+	t := reflect.TypeOf(p.v)
+	if t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return fmt.Errorf("fbapi: FieldsOf requires a struct, got %T", p.v)
+	}
+	fields := make([]string, 0, t.NumField())
+	for _, f := range structFields(t, true) {
+		fields = append(fields, f.String())
+	}
+	return paramFields(fields).Set(values)
+}
+
+// FieldsOf specifies the fields to include based on the JSON encoding of v,
+// a struct or a pointer to one, keeping the requested fields in sync with the
+// result. Fields named "-" are skipped, and struct fields are expanded one
+// level deep, so From struct{ID, Name string} requests from{id,name} given
+// the appropriate json tags. Types which unmarshal themselves, like
+// time.Time, aren't expanded. Setting the param fails if v isn't a struct.
+func FieldsOf(v interface{}) Param {
+	return paramFieldsOf{v: v}
+}
+
+// Returns the fields param to use for the result as per AutoFields, or nil if
+// the result isn't a pointer to a struct or is an edge response whose items
+// aren't structs.
+func autoFieldsOf(result interface{}) Param {
+	t := reflect.TypeOf(result)
+	if t == nil || t.Kind() != reflect.Ptr || t.Elem().Kind() != reflect.Struct {
+		return nil
+	}
+	t = t.Elem()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag := sf.Tag.Get("json")
+		if i := strings.Index(tag, ","); i >= 0 {
+			tag = tag[:i]
+		}
+		if tag != "data" && (tag != "" || !strings.EqualFold(sf.Name, "data")) {
+			continue
+		}
+		// an edge response, the fields apply to the items
+		item := sf.Type
+		if item.Kind() != reflect.Slice && item.Kind() != reflect.Array {
+			return nil
+		}
+		item = item.Elem()
+		if item.Kind() == reflect.Ptr {
+			item = item.Elem()
+		}
+		if item.Kind() != reflect.Struct || reflect.PtrTo(item).Implements(jsonUnmarshalerType) {
+			return nil
+		}
+		return FieldsOf(reflect.New(item).Interface())
+	}
+	return FieldsOf(result)
+}
+
+// Returns the Fields for the JSON encoding of the struct type t, with struct
+// fields expanded if expand is true.
+func structFields(t reflect.Type, expand bool) []Field {
+	var fields []Field
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag := sf.Tag.Get("json")
+		if tag == "-" || (sf.PkgPath != "" && !sf.Anonymous) {
+			continue
+		}
+		name := sf.Name
+		if i := strings.Index(tag, ","); i >= 0 {
+			tag = tag[:i]
+		}
+		if tag != "" {
+			name = tag
+		}
+
+		ft := sf.Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		isStruct := ft.Kind() == reflect.Struct &&
+			!reflect.PtrTo(ft).Implements(jsonUnmarshalerType)
+
+		// like encoding/json, the fields of untagged embedded structs are
+		// promoted
+		if sf.Anonymous && tag == "" {
+			if isStruct {
+				fields = append(fields, structFields(ft, expand)...)
+			}
+			continue
+		}
+
+		field := Field{Name: name}
+		if isStruct && expand {
+			field.Fields = structFields(ft, false)
+		}
+		fields = append(fields, field)
+	}
+	return fields
+}
+
+// Splits a fields spec like "id,friends.limit(5){id,name}" into its top level
+// fields, skipping empty ones.
+func splitFields(fields string) []string {
+	var split []string
+	var nesting, start int
+	add := func(end int) {
+		if f := strings.TrimSpace(fields[start:end]); f != "" {
+			split = append(split, f)
+		}
+		start = end + 1
+	}
+	for i, r := range fields {
+		switch r {
+		case '(', '{':
+			nesting++
+		case ')', '}':
+			nesting--
+		case ',':
+			if nesting == 0 {
+				add(i)
+			}
+		}
+	}
+	add(len(fields))
+	return split
+}
+
+// Returns the maximum nesting depth of the field expansions in a fields spec
+// like "id,friends.limit(5){id,name}", along with the largest number of fields
+// selected at any one level. Modifiers in parens are skipped.
+func fieldStats(fields string) (depth, breadth int) {
+	counts := []int{0}
+	var parens int
+	var inName bool
+	for _, r := range fields {
+		if parens > 0 {
+			switch r {
+			case '(':
+				parens++
+			case ')':
+				parens--
+			}
+			continue
+		}
+		switch r {
+		case '(':
+			parens++
+		case ',', ' ':
+			inName = false
+		case '{':
+			counts = append(counts, 0)
+			if len(counts)-1 > depth {
+				depth = len(counts) - 1
+			}
+			inName = false
+		case '}':
+			if len(counts) == 1 {
+				continue
+			}
+			if n := counts[len(counts)-1]; n > breadth {
+				breadth = n
+			}
+			counts = counts[:len(counts)-1]
+			inName = true
+		default:
+			if !inName {
+				counts[len(counts)-1]++
+				inName = true
+			}
+		}
+	}
+	for _, n := range counts {
+		if n > breadth {
+			breadth = n
+		}
+	}
+	return depth, breadth
+}
+
+// Log a warning if the fields requested exceed the configured limits.
+func (c *Client) checkFields(req *http.Request) {
+	if c.Logger == nil || (c.MaxFieldDepth == 0 && c.MaxFieldBreadth == 0) {
+		return
+	}
+	fields := req.URL.Query().Get("fields")
+	if fields == "" {
+		return
+	}
+	depth, breadth := fieldStats(fields)
+	if c.MaxFieldDepth != 0 && depth > c.MaxFieldDepth {
+		c.Logger.Printf(
+			"fbapi: fields nested %d levels deep, more than %d: %s",
+			depth, c.MaxFieldDepth, fields)
+	}
+	if c.MaxFieldBreadth != 0 && breadth > c.MaxFieldBreadth {
+		c.Logger.Printf(
+			"fbapi: %d fields selected at one level, more than %d: %s",
+			breadth, c.MaxFieldBreadth, fields)
+	}
+}
@@ -0,0 +1,256 @@
+package fbapi_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/facebookgo/ensure"
+	"github.com/facebookgo/fbapi"
+)
+
+type recordingLogger struct {
+	lines []string
+}
+
+func (l *recordingLogger) Printf(format string, v ...interface{}) {
+	l.lines = append(l.lines, fmt.Sprintf(format, v...))
+}
+
+func TestFieldExpansionWarnings(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		Fields   string
+		Warnings int
+	}{
+		{Fields: "id,name", Warnings: 0},
+		{Fields: "id,friends.limit(5){id,name}", Warnings: 0},
+		{Fields: "friends{friends{id}}", Warnings: 0},
+		{Fields: "friends{friends{friends{id}}}", Warnings: 1},
+		{Fields: "a,b,c,d,e", Warnings: 1},
+		{Fields: "id,friends{a,b,c,d,e}", Warnings: 1},
+		{Fields: "id,friends.fields(a,b,c,d,e)", Warnings: 0},
+		{Fields: "f{f{f{a,b,c,d,e}}}", Warnings: 2},
+	}
+	for _, tc := range cases {
+		logger := &recordingLogger{}
+		c := &fbapi.Client{
+			Logger:          logger,
+			MaxFieldDepth:   2,
+			MaxFieldBreadth: 4,
+			Transport: fTransport(func(r *http.Request) (*http.Response, error) {
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       ioutil.NopCloser(strings.NewReader("{}")),
+				}, nil
+			}),
+		}
+		_, err := c.Do(&http.Request{
+			Method: "GET",
+			URL: &url.URL{
+				Path:     "me",
+				RawQuery: url.Values{"fields": []string{tc.Fields}}.Encode(),
+			},
+		}, nil)
+		ensure.Nil(t, err)
+		ensure.DeepEqual(t, len(logger.lines), tc.Warnings, tc.Fields, logger.lines)
+	}
+}
+
+func TestFieldString(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		Field    fbapi.Field
+		Expected string
+	}{
+		{
+			Field:    fbapi.Field{Name: "id"},
+			Expected: "id",
+		},
+		{
+			Field: fbapi.Field{
+				Name:   "comments",
+				Order:  "reverse_chronological",
+				Fields: []fbapi.Field{{Name: "message"}, {Name: "from"}},
+			},
+			Expected: "comments.order(reverse_chronological){message,from}",
+		},
+		{
+			Field: fbapi.Field{
+				Name: "posts",
+				Fields: []fbapi.Field{
+					{Name: "message"},
+					{
+						Name:   "comments",
+						Limit:  5,
+						Order:  "chronological",
+						Fields: []fbapi.Field{{Name: "from", Fields: []fbapi.Field{{Name: "name"}}}},
+					},
+				},
+			},
+			Expected: "posts{message,comments.limit(5).order(chronological){from{name}}}",
+		},
+	}
+	for _, tc := range cases {
+		ensure.DeepEqual(t, tc.Field.String(), tc.Expected)
+	}
+
+	v, err := fbapi.ParamValues(fbapi.ParamFields("id", fbapi.Field{
+		Name:  "comments",
+		Order: "reverse_chronological",
+	}.String()))
+	ensure.Nil(t, err)
+	ensure.DeepEqual(t, v.Get("fields"), "id,comments.order(reverse_chronological)")
+}
+
+type fieldsOfBase struct {
+	ID string `json:"id"`
+}
+
+type fieldsOfPost struct {
+	fieldsOfBase
+	Message     string         `json:"message,omitempty"`
+	Ignored     string         `json:"-"`
+	CreatedTime fbapi.UnixTime `json:"created_time"`
+	From        *struct {
+		ID      string `json:"id"`
+		Name    string `json:"name"`
+		Picture struct {
+			URL string `json:"url"`
+		} `json:"picture"`
+	} `json:"from"`
+	Shares struct {
+		Count int `json:"count"`
+	} `json:"shares"`
+	Untagged string
+	private  string
+}
+
+func TestFieldsOf(t *testing.T) {
+	t.Parallel()
+	for _, v := range []interface{}{fieldsOfPost{}, &fieldsOfPost{}} {
+		values, err := fbapi.ParamValues(fbapi.FieldsOf(v))
+		ensure.Nil(t, err)
+		ensure.DeepEqual(t, values.Get("fields"),
+			"id,message,created_time,from{id,name,picture},shares{count},Untagged")
+	}
+}
+
+func TestFieldsOfNonStruct(t *testing.T) {
+	t.Parallel()
+	for _, v := range []interface{}{nil, "", []fieldsOfPost{}} {
+		_, err := fbapi.ParamValues(fbapi.FieldsOf(v))
+		ensure.Err(t, err, regexp.MustCompile("FieldsOf requires a struct"))
+	}
+}
+
+type autoFieldsBase struct {
+	ID string `json:"id"`
+}
+
+func TestAutoFields(t *testing.T) {
+	t.Parallel()
+	var user struct {
+		autoFieldsBase
+		Name     string `json:"name"`
+		Internal string `json:"-"`
+		Picture  struct {
+			URL string `json:"url"`
+		} `json:"picture"`
+	}
+	c := bodyClient(t, "", url.Values{"fields": {"id,name,picture{url}"}}, `{"id":"1"}`)
+	c.AutoFields = true
+	_, err := c.Get(context.Background(), "me", &user)
+	ensure.Nil(t, err)
+	ensure.DeepEqual(t, user.ID, "1")
+}
+
+func TestAutoFieldsExplicit(t *testing.T) {
+	t.Parallel()
+	var user struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	}
+	c := bodyClient(t, "", url.Values{"fields": {"id"}}, `{"id":"1"}`)
+	c.AutoFields = true
+	_, err := c.Get(context.Background(), "me", &user, fbapi.ParamFields("id"))
+	ensure.Nil(t, err)
+	_, err = c.Get(context.Background(), "me?fields=id", &user)
+	ensure.Nil(t, err)
+}
+
+func TestAutoFieldsNonStruct(t *testing.T) {
+	t.Parallel()
+	c := bodyClient(t, "", url.Values{"fields": nil}, `{"id":"1"}`)
+	c.AutoFields = true
+	var m map[string]string
+	_, err := c.Get(context.Background(), "me", &m)
+	ensure.Nil(t, err)
+	_, err = c.Get(context.Background(), "me", nil)
+	ensure.Nil(t, err)
+}
+
+func TestAutoFieldsEdge(t *testing.T) {
+	t.Parallel()
+	var page struct {
+		Data []*struct {
+			ID   string `json:"id"`
+			Name string `json:"name"`
+		} `json:"data"`
+		Paging *fbapi.Paging `json:"paging"`
+	}
+	c := bodyClient(t, "", url.Values{"fields": {"id,name"}}, `{"id":"1"}`)
+	c.AutoFields = true
+	_, err := c.Get(context.Background(), "me/friends", &page)
+	ensure.Nil(t, err)
+
+	var raw struct {
+		Data []json.RawMessage `json:"data"`
+	}
+	c = bodyClient(t, "", url.Values{"fields": nil}, `{"id":"1"}`)
+	c.AutoFields = true
+	_, err = c.Get(context.Background(), "me/friends", &raw)
+	ensure.Nil(t, err)
+}
+
+func TestAutoFieldsHelpers(t *testing.T) {
+	t.Parallel()
+	c := &fbapi.Client{
+		AutoFields: true,
+		Transport: fTransport(func(r *http.Request) (*http.Response, error) {
+			_, ok := r.URL.Query()["fields"]
+			ensure.False(t, ok, r.URL)
+			body := `{"data":[{"id":"1","name":"Page"}]}`
+			if r.URL.Path == "/debug_token" {
+				body = debugTokenBody
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(strings.NewReader(body)),
+			}, nil
+		}),
+	}
+	accounts, err := c.Accounts(context.Background())
+	ensure.Nil(t, err)
+	ensure.DeepEqual(t, accounts, []fbapi.Account{{ID: "1", Name: "Page"}})
+
+	info, err := c.DebugToken(context.Background(), "user-token", "app-token")
+	ensure.Nil(t, err)
+	ensure.DeepEqual(t, info.AppID, "42")
+}
+
+func TestAutoFieldsDisabled(t *testing.T) {
+	t.Parallel()
+	c := bodyClient(t, "", url.Values{"fields": nil}, `{"id":"1"}`)
+	var user struct {
+		ID string `json:"id"`
+	}
+	_, err := c.Get(context.Background(), "me", &user)
+	ensure.Nil(t, err)
+}
@@ -0,0 +1,40 @@
+package fbapi
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// FQL runs an FQL query and unmarshals the rows of the response into result,
+// typically a pointer to a slice. FQL is deprecated and only available to
+// apps which can still use API version 2.0, so the Client Version should be
+// set accordingly.
+func (c *Client) FQL(ctx context.Context, query string, result interface{}) error {
+	var body json.RawMessage
+	if _, err := c.get(ctx, "fql", &body, ParamString("q", query)); err != nil {
+		return err
+	}
+	return DecodeData(body, result)
+}
+
+// FQLMulti runs several named FQL queries in a single request, where queries
+// can use the results of others by referring to them as #name. The rows of
+// each query are returned by name, to be unmarshalled as needed.
+func (c *Client) FQLMulti(ctx context.Context, queries map[string]string) (map[string]json.RawMessage, error) {
+	var body json.RawMessage
+	if _, err := c.get(ctx, "fql", &body, ParamJSON("q", queries)); err != nil {
+		return nil, err
+	}
+	var resultSets []struct {
+		Name string          `json:"name"`
+		Rows json.RawMessage `json:"fql_result_set"`
+	}
+	if err := DecodeData(body, &resultSets); err != nil {
+		return nil, err
+	}
+	results := make(map[string]json.RawMessage, len(resultSets))
+	for _, rs := range resultSets {
+		results[rs.Name] = rs.Rows
+	}
+	return results, nil
+}
@@ -0,0 +1,80 @@
+package fbapi_test
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/facebookgo/ensure"
+	"github.com/facebookgo/fbapi"
+)
+
+func TestFQL(t *testing.T) {
+	t.Parallel()
+	const query = "SELECT uid, name FROM user WHERE uid = me()"
+	c := &fbapi.Client{
+		Version: "2.0",
+		Transport: fTransport(func(r *http.Request) (*http.Response, error) {
+			ensure.DeepEqual(t, r.URL.Path, "/v2.0/fql")
+			ensure.DeepEqual(t, r.URL.Query().Get("q"), query)
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(strings.NewReader(`{"data":[{"uid":1,"name":"Me"}]}`)),
+			}, nil
+		}),
+	}
+	var users []struct {
+		UID  uint64 `json:"uid"`
+		Name string `json:"name"`
+	}
+	ensure.Nil(t, c.FQL(context.Background(), query, &users))
+	ensure.DeepEqual(t, len(users), 1)
+	ensure.DeepEqual(t, users[0].UID, uint64(1))
+	ensure.DeepEqual(t, users[0].Name, "Me")
+}
+
+func TestFQLError(t *testing.T) {
+	t.Parallel()
+	c := &fbapi.Client{
+		Transport: fTransport(func(r *http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: http.StatusBadRequest,
+				Body:       ioutil.NopCloser(strings.NewReader(`{"error":{"code":12,"message":"fql is deprecated"}}`)),
+			}, nil
+		}),
+	}
+	var rows []json.RawMessage
+	err := c.FQL(context.Background(), "SELECT name FROM user", &rows)
+	ensure.DeepEqual(t, withoutResponse(err), &fbapi.Error{Code: 12, Message: "fql is deprecated"})
+}
+
+func TestFQLMulti(t *testing.T) {
+	t.Parallel()
+	queries := map[string]string{
+		"friends": "SELECT uid2 FROM friend WHERE uid1 = me()",
+		"names":   "SELECT name FROM user WHERE uid IN (SELECT uid2 FROM #friends)",
+	}
+	c := &fbapi.Client{
+		Transport: fTransport(func(r *http.Request) (*http.Response, error) {
+			var actual map[string]string
+			ensure.Nil(t, json.Unmarshal([]byte(r.URL.Query().Get("q")), &actual))
+			ensure.DeepEqual(t, actual, queries)
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body: ioutil.NopCloser(strings.NewReader(`{"data":[
+					{"name":"friends","fql_result_set":[{"uid2":"2"}]},
+					{"name":"names","fql_result_set":[{"name":"Friend"}]}
+				]}`)),
+			}, nil
+		}),
+	}
+	results, err := c.FQLMulti(context.Background(), queries)
+	ensure.Nil(t, err)
+	ensure.DeepEqual(t, results, map[string]json.RawMessage{
+		"friends": json.RawMessage(`[{"uid2":"2"}]`),
+		"names":   json.RawMessage(`[{"name":"Friend"}]`),
+	})
+}
@@ -0,0 +1,114 @@
+package fbapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"time"
+)
+
+var errNoPeriod = errors.New("fbapi: no insights period given")
+
+// InsightValue is the value of an insights metric for the period ending at
+// EndTime. The Value is a number for most metrics, but some have an object
+// breaking it down, so it is left to be decoded as appropriate.
+type InsightValue struct {
+	Value   json.RawMessage `json:"value"`
+	EndTime UnixTime        `json:"end_time"`
+}
+
+// Insights fetches the metrics of the /{id}/insights edge for the given period,
+// such as "day" or "days_28", and the values ending in the date range from
+// since to until, following the paging as necessary. The values are returned
+// keyed by metric name, ordered by EndTime. The period is required as the API
+// otherwise returns the values of all periods under the same name. Params are
+// sent with the first request. A zero since or until is not sent, using the
+// API default for it.
+func (c *Client) Insights(
+	ctx context.Context,
+	id string,
+	metrics []string,
+	period string,
+	since, until time.Time,
+	params ...Param,
+) (map[string][]InsightValue, error) {
+	if period == "" {
+		return nil, errNoPeriod
+	}
+	params = append(params[:len(params):len(params)],
+		ParamList("metric", metrics...), ParamString("period", period))
+	if !since.IsZero() {
+		params = append(params, ParamInt("since", since.Unix()))
+	}
+	if !until.IsZero() {
+		params = append(params, ParamInt("until", until.Unix()))
+	}
+
+	var body json.RawMessage
+	if _, err := c.get(ctx, id+"/insights", &body, params...); err != nil {
+		return nil, err
+	}
+
+	series := make(map[string]map[int64]InsightValue)
+	for {
+		var page struct {
+			Data []struct {
+				Name   string         `json:"name"`
+				Period string         `json:"period"`
+				Values []InsightValue `json:"values"`
+			} `json:"data"`
+			Paging *Paging `json:"paging"`
+		}
+		if err := json.Unmarshal(body, &page); err != nil {
+			return nil, err
+		}
+
+		// the API keeps providing pages for adjacent ranges, so stop at the
+		// first page without any values in the range
+		var inRange bool
+		for _, metric := range page.Data {
+			if metric.Period != "" && metric.Period != period {
+				continue
+			}
+			for _, value := range metric.Values {
+				t := value.EndTime.Time
+				if (!since.IsZero() && t.Before(since)) || (!until.IsZero() && t.After(until)) {
+					continue
+				}
+				inRange = true
+				if series[metric.Name] == nil {
+					series[metric.Name] = make(map[int64]InsightValue)
+				}
+				series[metric.Name][t.Unix()] = value
+			}
+		}
+		if !inRange {
+			break
+		}
+
+		body = nil
+		if _, ok, err := c.Next(ctx, page.Paging, &body); err != nil {
+			return nil, err
+		} else if !ok {
+			break
+		}
+	}
+
+	result := make(map[string][]InsightValue, len(series))
+	for name, byTime := range series {
+		values := make([]InsightValue, 0, len(byTime))
+		for _, value := range byTime {
+			values = append(values, value)
+		}
+		sort.Sort(insightValuesByTime(values))
+		result[name] = values
+	}
+	return result, nil
+}
+
+type insightValuesByTime []InsightValue
+
+func (v insightValuesByTime) Len() int           { return len(v) }
+func (v insightValuesByTime) Less(i, j int) bool { return v[i].EndTime.Before(v[j].EndTime.Time) }
+func (v insightValuesByTime) Swap(i, j int)      { v[i], v[j] = v[j], v[i] }
@@ -0,0 +1,114 @@
+package fbapi_test
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/facebookgo/ensure"
+	"github.com/facebookgo/fbapi"
+)
+
+func TestInsights(t *testing.T) {
+	t.Parallel()
+	const next = "https://graph.facebook.com/42/insights?metric=page_fans,page_views&since=3&until=5"
+	pages := map[string]string{
+		"https://graph.facebook.com/42/insights?metric=page_fans%2Cpage_views&period=day&since=0&until=300000": `{
+			"data": [
+				{"name": "page_fans", "period": "week", "values": [
+					{"value": 9, "end_time": "1970-01-02T00:00:00+0000"}
+				]},
+				{"name": "page_fans", "period": "day", "values": [
+					{"value": 2, "end_time": "1970-01-02T00:00:00+0000"},
+					{"value": 1, "end_time": "1970-01-01T00:00:00+0000"}
+				]},
+				{"name": "page_views", "values": [
+					{"value": 10, "end_time": "1970-01-01T00:00:00+0000"}
+				]}
+			],
+			"paging": {"next": "` + next + `"}
+		}`,
+		next: `{
+			"data": [
+				{"name": "page_fans", "values": [
+					{"value": 3, "end_time": "1970-01-03T00:00:00+0000"},
+					{"value": 4, "end_time": "1970-01-05T00:00:00+0000"}
+				]},
+				{"name": "page_views", "values": [
+					{"value": 30, "end_time": "1970-01-03T00:00:00+0000"},
+					{"value": 20, "end_time": "1970-01-02T00:00:00+0000"}
+				]}
+			],
+			"paging": {"next": "https://graph.facebook.com/42/insights?since=5"}
+		}`,
+		"https://graph.facebook.com/42/insights?since=5": `{
+			"data": [
+				{"name": "page_fans", "values": [
+					{"value": 5, "end_time": "1970-01-06T00:00:00+0000"}
+				]}
+			],
+			"paging": {"next": "https://graph.facebook.com/42/insights?since=6"}
+		}`,
+	}
+	c := &fbapi.Client{
+		Transport: fTransport(func(r *http.Request) (*http.Response, error) {
+			body, ok := pages[r.URL.String()]
+			ensure.True(t, ok, r.URL.String())
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(strings.NewReader(body)),
+			}, nil
+		}),
+	}
+	series, err := c.Insights(
+		context.Background(),
+		"42",
+		[]string{"page_fans", "page_views"},
+		"day",
+		time.Unix(0, 0),
+		time.Unix(300000, 0),
+	)
+	ensure.Nil(t, err)
+
+	values := func(metric string) []string {
+		var actual []string
+		for _, v := range series[metric] {
+			actual = append(actual, v.EndTime.UTC().Format("02")+"="+string(v.Value))
+		}
+		return actual
+	}
+	ensure.DeepEqual(t, len(series), 2)
+	ensure.DeepEqual(t, values("page_fans"), []string{"01=1", "02=2", "03=3"})
+	ensure.DeepEqual(t, values("page_views"), []string{"01=10", "02=20", "03=30"})
+	ensure.DeepEqual(t, series["page_fans"][0].Value, json.RawMessage("1"))
+}
+
+func TestInsightsError(t *testing.T) {
+	t.Parallel()
+	c := &fbapi.Client{
+		Transport: fTransport(func(r *http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: http.StatusBadRequest,
+				Body:       ioutil.NopCloser(strings.NewReader(`{"error":{"code":100}}`)),
+			}, nil
+		}),
+	}
+	_, err := c.Insights(context.Background(), "42", []string{"page_fans"}, "day", time.Time{}, time.Time{})
+	ensure.DeepEqual(t, withoutResponse(err), &fbapi.Error{Code: 100})
+}
+
+func TestInsightsNoPeriod(t *testing.T) {
+	t.Parallel()
+	c := &fbapi.Client{
+		Transport: fTransport(func(r *http.Request) (*http.Response, error) {
+			panic("not reached")
+		}),
+	}
+	_, err := c.Insights(context.Background(), "42", []string{"page_fans"}, "", time.Time{}, time.Time{})
+	ensure.Err(t, err, regexp.MustCompile("no insights period"))
+}
@@ -0,0 +1,109 @@
+package fbapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+)
+
+var errNoPage = errors.New("fbapi: iterator has no current page")
+
+// Iterator walks every page of an edge, following the paging next URLs. Only
+// the current page is kept in memory:
+//
+//	it := fbapi.NewIterator(c, "me/feed", fbapi.ParamLimit(100))
+//	for it.Next(ctx) {
+//		var page struct {
+//			Data []Post `json:"data"`
+//		}
+//		if err := it.Scan(&page); err != nil {
+//			return err
+//		}
+//	}
+//	if err := it.Err(); err != nil {
+//		return err
+//	}
+type Iterator struct {
+	client *Client
+	path   string
+	params []Param
+
+	started bool
+	page    json.RawMessage
+	paging  *Paging
+	summary *Summary
+	err     error
+}
+
+// NewIterator returns an Iterator over the edge at path, whose first page is
+// fetched with the params. The params are included in the next URLs of the
+// following pages by the API.
+func NewIterator(c *Client, path string, params ...Param) *Iterator {
+	return &Iterator{client: c, path: path, params: params}
+}
+
+// Next fetches the next page, returning false when there are no more pages or
+// an error occurred, which is then returned by Err.
+func (it *Iterator) Next(ctx context.Context) bool {
+	if it.err != nil {
+		return false
+	}
+
+	var body json.RawMessage
+	if !it.started {
+		it.started = true
+		if _, err := it.client.Get(ctx, it.path, &body, it.params...); err != nil {
+			return it.fail(err)
+		}
+	} else {
+		if it.paging == nil || it.paging.Next == "" {
+			it.page = nil
+			return false
+		}
+		if _, err := it.client.Get(ctx, it.paging.Next, &body); err != nil {
+			return it.fail(err)
+		}
+	}
+
+	var page struct {
+		Paging  *Paging  `json:"paging"`
+		Summary *Summary `json:"summary"`
+	}
+	if err := json.Unmarshal(body, &page); err != nil {
+		return it.fail(err)
+	}
+	it.page = body
+	it.paging = page.Paging
+	it.summary = page.Summary
+	return true
+}
+
+// Scan unmarshals the current page into v.
+func (it *Iterator) Scan(v interface{}) error {
+	if it.page == nil {
+		return errNoPage
+	}
+	return json.Unmarshal(it.page, v)
+}
+
+// Paging returns the Paging of the current page, which is nil if it has none.
+func (it *Iterator) Paging() *Paging {
+	return it.paging
+}
+
+// Summary returns the Summary of the current page, which is nil if it has
+// none. It is only included when requested with ParamSummary.
+func (it *Iterator) Summary() *Summary {
+	return it.summary
+}
+
+// Err returns the error which stopped the iteration, if any.
+func (it *Iterator) Err() error {
+	return it.err
+}
+
+func (it *Iterator) fail(err error) bool {
+	it.err = err
+	it.page = nil
+	return false
+}
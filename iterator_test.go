@@ -0,0 +1,108 @@
+package fbapi_test
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/facebookgo/ensure"
+	"github.com/facebookgo/fbapi"
+)
+
+type idPage struct {
+	Data []struct {
+		ID string `json:"id"`
+	} `json:"data"`
+}
+
+func TestIterator(t *testing.T) {
+	t.Parallel()
+	transport, calls := sequenceTransport(t,
+		fakeResponse{Code: http.StatusOK, Body: `{
+			"data": [{"id": "1"}, {"id": "2"}],
+			"paging": {"next": "https://graph.facebook.com/me/feed?after=Mg"}
+		}`},
+		fakeResponse{Code: http.StatusOK, Body: `{
+			"data": [{"id": "3"}],
+			"paging": {"cursors": {"after": "Mw"}}
+		}`},
+	)
+	var urls []string
+	c := &fbapi.Client{
+		Transport: fTransport(func(r *http.Request) (*http.Response, error) {
+			urls = append(urls, r.URL.String())
+			return transport.RoundTrip(r)
+		}),
+	}
+	it := fbapi.NewIterator(c, "me/feed", fbapi.ParamLimit(2))
+	var ids []string
+	for it.Next(context.Background()) {
+		var page idPage
+		ensure.Nil(t, it.Scan(&page))
+		for _, item := range page.Data {
+			ids = append(ids, item.ID)
+		}
+	}
+	ensure.Nil(t, it.Err())
+	ensure.DeepEqual(t, ids, []string{"1", "2", "3"})
+	ensure.DeepEqual(t, *calls, 2)
+	ensure.DeepEqual(t, urls, []string{
+		"https://graph.facebook.com/me/feed?limit=2",
+		"https://graph.facebook.com/me/feed?after=Mg",
+	})
+	ensure.False(t, it.Next(context.Background()))
+	ensure.NotNil(t, it.Scan(&idPage{}))
+}
+
+func TestIteratorError(t *testing.T) {
+	t.Parallel()
+	transport, calls := sequenceTransport(t,
+		fakeResponse{Code: http.StatusOK, Body: `{
+			"data": [{"id": "1"}],
+			"paging": {"next": "https://graph.facebook.com/me/feed?after=MQ"}
+		}`},
+		fakeResponse{Code: http.StatusBadRequest, Body: `{"error":{"code":100}}`},
+	)
+	it := fbapi.NewIterator(&fbapi.Client{Transport: transport}, "me/feed")
+	ensure.True(t, it.Next(context.Background()))
+	ensure.DeepEqual(t, it.Paging(), &fbapi.Paging{Next: "https://graph.facebook.com/me/feed?after=MQ"})
+	ensure.False(t, it.Next(context.Background()))
+	ensure.DeepEqual(t, withoutResponse(it.Err()), &fbapi.Error{Code: 100})
+	ensure.False(t, it.Next(context.Background()))
+	ensure.DeepEqual(t, *calls, 2)
+}
+
+func TestIteratorNoPaging(t *testing.T) {
+	t.Parallel()
+	transport, calls := sequenceTransport(t,
+		fakeResponse{Code: http.StatusOK, Body: `{"data": []}`},
+	)
+	it := fbapi.NewIterator(&fbapi.Client{Transport: transport}, "me/feed")
+	ensure.True(t, it.Next(context.Background()))
+	ensure.True(t, it.Paging() == nil)
+	ensure.False(t, it.Next(context.Background()))
+	ensure.Nil(t, it.Err())
+	ensure.DeepEqual(t, *calls, 1)
+}
+
+func TestIteratorSummary(t *testing.T) {
+	t.Parallel()
+	c := &fbapi.Client{
+		Transport: fTransport(func(r *http.Request) (*http.Response, error) {
+			ensure.DeepEqual(t, r.URL.Query().Get("summary"), "true")
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body: ioutil.NopCloser(strings.NewReader(`{
+					"data": [{"id": "1"}],
+					"summary": {"total_count": 1234}
+				}`)),
+			}, nil
+		}),
+	}
+	it := fbapi.NewIterator(c, "1/comments", fbapi.ParamSummary())
+	ensure.True(t, it.Summary() == nil)
+	ensure.True(t, it.Next(context.Background()))
+	ensure.DeepEqual(t, it.Summary(), &fbapi.Summary{TotalCount: 1234})
+}
@@ -0,0 +1,81 @@
+package fbapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var errMissingAccessToken = errors.New("fbapi: response does not include an access token")
+
+// ExchangeToken exchanges a short lived user access token for a long lived
+// one using /oauth/access_token, returning the new token and the duration it
+// expires in, which is zero if not provided. Both the JSON response of current
+// API versions and the URL encoded one of older versions are supported. The
+// app secret is redacted from errors.
+func (c *Client) ExchangeToken(ctx context.Context, appID, appSecret, shortToken string) (string, time.Duration, error) {
+	token, expiresIn, err := c.oauthAccessToken(ctx,
+		ParamString("grant_type", "fb_exchange_token"),
+		ParamString("client_id", appID),
+		ParamString("client_secret", appSecret),
+		ParamString("fb_exchange_token", shortToken),
+	)
+	if err != nil {
+		return "", 0, redactError(err, appSecret)
+	}
+	return token, expiresIn, nil
+}
+
+// AppAccessToken fetches an app access token using /oauth/access_token with the
+// client_credentials grant. Note the app token can also be built without a
+// request as appID + "|" + appSecret. The app secret is redacted from errors.
+func (c *Client) AppAccessToken(ctx context.Context, appID, appSecret string) (string, error) {
+	token, _, err := c.oauthAccessToken(ctx,
+		ParamString("grant_type", "client_credentials"),
+		ParamString("client_id", appID),
+		ParamString("client_secret", appSecret),
+	)
+	if err != nil {
+		return "", redactError(err, appSecret)
+	}
+	return token, nil
+}
+
+// Fetch a token from /oauth/access_token, parsing either a JSON or a URL
+// encoded response.
+func (c *Client) oauthAccessToken(ctx context.Context, params ...Param) (string, time.Duration, error) {
+	var body bytes.Buffer
+	if _, err := c.get(ctx, "oauth/access_token", &body, params...); err != nil {
+		return "", 0, err
+	}
+
+	var res struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}
+	if trimmed := strings.TrimSpace(body.String()); strings.HasPrefix(trimmed, "{") {
+		if err := json.Unmarshal(body.Bytes(), &res); err != nil {
+			return "", 0, err
+		}
+	} else {
+		v, err := url.ParseQuery(trimmed)
+		if err != nil {
+			return "", 0, err
+		}
+		res.AccessToken = v.Get("access_token")
+		if expires := v.Get("expires"); expires != "" {
+			if res.ExpiresIn, err = strconv.ParseInt(expires, 10, 64); err != nil {
+				return "", 0, err
+			}
+		}
+	}
+	if res.AccessToken == "" {
+		return "", 0, errMissingAccessToken
+	}
+	return res.AccessToken, time.Duration(res.ExpiresIn) * time.Second, nil
+}
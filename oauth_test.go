@@ -0,0 +1,131 @@
+package fbapi_test
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/facebookgo/ensure"
+	"github.com/facebookgo/fbapi"
+)
+
+var exchangeTokenQuery = url.Values{
+	"grant_type":        {"fb_exchange_token"},
+	"client_id":         {"42"},
+	"client_secret":     {"s3cret"},
+	"fb_exchange_token": {"short"},
+}
+
+func TestExchangeTokenJSON(t *testing.T) {
+	t.Parallel()
+	c := bodyClient(t, "/oauth/access_token", exchangeTokenQuery, `{"access_token":"long","token_type":"bearer","expires_in":5183999}`)
+	token, expiresIn, err := c.ExchangeToken(context.Background(), "42", "s3cret", "short")
+	ensure.Nil(t, err)
+	ensure.DeepEqual(t, token, "long")
+	ensure.DeepEqual(t, expiresIn, 5183999*time.Second)
+}
+
+func TestExchangeTokenURLEncoded(t *testing.T) {
+	t.Parallel()
+	c := bodyClient(t, "/oauth/access_token", exchangeTokenQuery, `access_token=long&expires=5183999`)
+	token, expiresIn, err := c.ExchangeToken(context.Background(), "42", "s3cret", "short")
+	ensure.Nil(t, err)
+	ensure.DeepEqual(t, token, "long")
+	ensure.DeepEqual(t, expiresIn, 5183999*time.Second)
+}
+
+func TestExchangeTokenNoExpiry(t *testing.T) {
+	t.Parallel()
+	c := bodyClient(t, "/oauth/access_token", exchangeTokenQuery, `{"access_token":"long"}`)
+	token, expiresIn, err := c.ExchangeToken(context.Background(), "42", "s3cret", "short")
+	ensure.Nil(t, err)
+	ensure.DeepEqual(t, token, "long")
+	ensure.DeepEqual(t, expiresIn, time.Duration(0))
+}
+
+func TestExchangeTokenMissingToken(t *testing.T) {
+	t.Parallel()
+	c := bodyClient(t, "/oauth/access_token", exchangeTokenQuery, `{}`)
+	_, _, err := c.ExchangeToken(context.Background(), "42", "s3cret", "short")
+	ensure.Err(t, err, regexp.MustCompile("does not include an access token"))
+}
+
+func TestExchangeTokenRedactsAPIError(t *testing.T) {
+	t.Parallel()
+	c := &fbapi.Client{
+		Transport: fTransport(func(r *http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: http.StatusBadRequest,
+				Body: ioutil.NopCloser(strings.NewReader(
+					`{"error":{"code":1,"message":"Invalid client_secret: s3cret"}}`)),
+			}, nil
+		}),
+	}
+	_, _, err := c.ExchangeToken(context.Background(), "42", "s3cret", "short")
+	ensure.DeepEqual(t, withoutResponse(err), &fbapi.Error{Code: 1, Message: "Invalid client_secret: REDACTED"})
+	apiErr := err.(*fbapi.Error)
+	for _, u := range []string{apiErr.Request().URL.String(), apiErr.Response().Request.URL.String(), err.Error()} {
+		ensure.StringDoesNotContain(t, u, "s3cret")
+		ensure.StringDoesNotContain(t, u, "short")
+	}
+}
+
+func TestExchangeTokenRedactsTransportError(t *testing.T) {
+	t.Parallel()
+	c := &fbapi.Client{
+		Transport: fTransport(func(r *http.Request) (*http.Response, error) {
+			return nil, errors.New("failed to get " + r.URL.String())
+		}),
+	}
+	_, _, err := c.ExchangeToken(context.Background(), "42", "s3cret", "short")
+	ensure.NotNil(t, err)
+	ensure.False(t, strings.Contains(err.Error(), "s3cret"), err)
+	ensure.StringContains(t, err.Error(), "client_secret=REDACTED")
+
+	_, _, err = c.ExchangeToken(context.Background(), "42", "s3cret/+", "short")
+	ensure.NotNil(t, err)
+	ensure.False(t, strings.Contains(err.Error(), "s3cret"), err)
+	ensure.StringContains(t, err.Error(), "client_secret=REDACTED")
+}
+
+func TestAppAccessToken(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ensure.DeepEqual(t, r.URL.Path, "/proxy/oauth/access_token")
+		q := r.URL.Query()
+		ensure.DeepEqual(t, q.Get("grant_type"), "client_credentials")
+		ensure.DeepEqual(t, q.Get("client_id"), "42")
+		ensure.DeepEqual(t, q.Get("client_secret"), "s3cret")
+		w.Write([]byte(`{"access_token":"42|app","token_type":"bearer"}`))
+	}))
+	defer server.Close()
+	baseURL, err := url.Parse(server.URL + "/proxy/")
+	ensure.Nil(t, err)
+
+	c := &fbapi.Client{BaseURL: baseURL}
+	token, err := c.AppAccessToken(context.Background(), "42", "s3cret")
+	ensure.Nil(t, err)
+	ensure.DeepEqual(t, token, "42|app")
+}
+
+func TestAppAccessTokenRedactsError(t *testing.T) {
+	t.Parallel()
+	c := &fbapi.Client{
+		Transport: fTransport(func(r *http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: http.StatusBadRequest,
+				Body: ioutil.NopCloser(strings.NewReader(
+					`{"error":{"code":101,"message":"Error validating client secret s3cret"}}`)),
+			}, nil
+		}),
+	}
+	_, err := c.AppAccessToken(context.Background(), "42", "s3cret")
+	ensure.DeepEqual(t, withoutResponse(err), &fbapi.Error{Code: 101, Message: "Error validating client secret REDACTED"})
+}
@@ -0,0 +1,121 @@
+package fbapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+)
+
+var errMissingData = errors.New("fbapi: response has no data")
+
+// Cursors for cursor based pagination.
+type Cursors struct {
+	Before string `json:"before"`
+	After  string `json:"after"`
+}
+
+// Paging is the paging object included in edge responses.
+type Paging struct {
+	Cursors  Cursors `json:"cursors"`
+	Next     string  `json:"next"`
+	Previous string  `json:"previous"`
+}
+
+// Summary is the summary object included in edge responses when requested
+// with ParamSummary. Only the total_count, which most edges provide, is
+// captured, use your own type to access the edge specific fields.
+type Summary struct {
+	TotalCount int `json:"total_count"`
+}
+
+// PageCount returns the number of pages of the given size needed to fetch all
+// the items, including the first page. The returned bool is false if it is
+// unknown because the summary is nil, as it is when absent from the response,
+// or the page size isn't positive. Items may be added or removed while paging
+// so this is only an estimate.
+func (s *Summary) PageCount(pageSize int) (int, bool) {
+	if s == nil || pageSize <= 0 {
+		return 0, false
+	}
+	return (s.TotalCount + pageSize - 1) / pageSize, true
+}
+
+// Next fetches the next page and unmarshals it into result, returning the
+// Paging of the fetched page, which is nil if it has none. The returned bool
+// is false, and nothing is fetched, if there is no next page. The next URL is
+// used as-is, it is absolute and already includes the params of the original
+// request, including the access token.
+func (c *Client) Next(ctx context.Context, paging *Paging, result interface{}) (*Paging, bool, error) {
+	if paging == nil || paging.Next == "" {
+		return nil, false, nil
+	}
+
+	var body json.RawMessage
+	if _, err := c.get(ctx, paging.Next, &body); err != nil {
+		return nil, false, err
+	}
+
+	var page struct {
+		Paging *Paging `json:"paging"`
+	}
+	if err := json.Unmarshal(body, &page); err != nil {
+		return nil, false, err
+	}
+	if result != nil {
+		if err := json.Unmarshal(body, result); err != nil {
+			return nil, false, err
+		}
+	}
+	return page.Paging, true, nil
+}
+
+// GetEdge fetches a page of the edge at path, unmarshals its data into data,
+// typically a pointer to a slice, and returns its Paging, which is nil if it
+// has none. An error is returned if the response has no data, as is the case
+// if path is a single object rather than an edge.
+func (c *Client) GetEdge(ctx context.Context, path string, data interface{}, params ...Param) (*Paging, error) {
+	var body json.RawMessage
+	if _, err := c.get(ctx, path, &body, params...); err != nil {
+		return nil, err
+	}
+	if err := DecodeData(body, data); err != nil {
+		return nil, err
+	}
+	var page struct {
+		Paging *Paging `json:"paging"`
+	}
+	if err := json.Unmarshal(body, &page); err != nil {
+		return nil, err
+	}
+	return page.Paging, nil
+}
+
+// DecodeSummary returns the summary of an edge response body, requested with
+// ParamSummary, which is nil if the body has none.
+func DecodeSummary(body []byte) (*Summary, error) {
+	var envelope struct {
+		Summary *Summary `json:"summary"`
+	}
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		return nil, err
+	}
+	return envelope.Summary, nil
+}
+
+// DecodeData unmarshals the data of an edge response body, which has the form
+// {"data": [...], "paging": {...}}, into out, typically a pointer to a slice.
+// Use a []json.RawMessage to defer decoding items of different types, such as
+// feed items, based on one of their fields. An error is returned if the body
+// has no data, as is the case for single objects.
+func DecodeData(body []byte, out interface{}) error {
+	var envelope struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		return err
+	}
+	if envelope.Data == nil {
+		return errMissingData
+	}
+	return json.Unmarshal(envelope.Data, out)
+}
@@ -0,0 +1,242 @@
+package fbapi_test
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/facebookgo/ensure"
+	"github.com/facebookgo/fbapi"
+)
+
+func TestNext(t *testing.T) {
+	t.Parallel()
+	const next = "https://graph.facebook.com/v2.5/me/feed?access_token=at&limit=1&after=MQ"
+	c := &fbapi.Client{
+		AccessToken: "other",
+		Transport: fTransport(func(r *http.Request) (*http.Response, error) {
+			ensure.DeepEqual(t, r.URL.String(), next)
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body: ioutil.NopCloser(strings.NewReader(`{
+					"data": [{"id": "2"}],
+					"paging": {
+						"cursors": {"before": "Mg", "after": "Mw"},
+						"previous": "https://graph.facebook.com/v2.5/me/feed?before=Mg",
+						"next": "https://graph.facebook.com/v2.5/me/feed?after=Mw"
+					}
+				}`)),
+			}, nil
+		}),
+	}
+	var page struct {
+		Data []struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	paging, ok, err := c.Next(context.Background(), &fbapi.Paging{Next: next}, &page)
+	ensure.Nil(t, err)
+	ensure.True(t, ok)
+	ensure.DeepEqual(t, len(page.Data), 1)
+	ensure.DeepEqual(t, page.Data[0].ID, "2")
+	ensure.DeepEqual(t, paging, &fbapi.Paging{
+		Cursors:  fbapi.Cursors{Before: "Mg", After: "Mw"},
+		Previous: "https://graph.facebook.com/v2.5/me/feed?before=Mg",
+		Next:     "https://graph.facebook.com/v2.5/me/feed?after=Mw",
+	})
+}
+
+func TestNextLastPage(t *testing.T) {
+	t.Parallel()
+	c := &fbapi.Client{
+		Transport: fTransport(func(r *http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(strings.NewReader(`{"data":[]}`)),
+			}, nil
+		}),
+	}
+	paging, ok, err := c.Next(context.Background(), &fbapi.Paging{Next: "https://graph.facebook.com/me/feed"}, nil)
+	ensure.Nil(t, err)
+	ensure.True(t, ok)
+	ensure.True(t, paging == nil)
+}
+
+func TestNextNoNextPage(t *testing.T) {
+	t.Parallel()
+	c := &fbapi.Client{
+		Transport: fTransport(func(r *http.Request) (*http.Response, error) {
+			panic("not reached")
+		}),
+	}
+	for _, paging := range []*fbapi.Paging{nil, {}, {Previous: "https://graph.facebook.com/me/feed"}} {
+		next, ok, err := c.Next(context.Background(), paging, nil)
+		ensure.Nil(t, err)
+		ensure.False(t, ok)
+		ensure.True(t, next == nil)
+	}
+}
+
+func TestSummaryPageCount(t *testing.T) {
+	t.Parallel()
+	var page struct {
+		Summary *fbapi.Summary `json:"summary"`
+	}
+	ensure.Nil(t, json.Unmarshal([]byte(`{"data":[],"summary":{"total_count":101}}`), &page))
+	count, ok := page.Summary.PageCount(25)
+	ensure.True(t, ok)
+	ensure.DeepEqual(t, count, 5)
+
+	count, ok = (&fbapi.Summary{TotalCount: 100}).PageCount(25)
+	ensure.True(t, ok)
+	ensure.DeepEqual(t, count, 4)
+
+	count, ok = (&fbapi.Summary{}).PageCount(25)
+	ensure.True(t, ok)
+	ensure.DeepEqual(t, count, 0)
+
+	_, ok = page.Summary.PageCount(0)
+	ensure.False(t, ok)
+}
+
+func TestSummaryPageCountAbsent(t *testing.T) {
+	t.Parallel()
+	var page struct {
+		Summary *fbapi.Summary `json:"summary"`
+	}
+	ensure.Nil(t, json.Unmarshal([]byte(`{"data":[]}`), &page))
+	count, ok := page.Summary.PageCount(25)
+	ensure.False(t, ok)
+	ensure.DeepEqual(t, count, 0)
+}
+
+func TestParamAfterFromPaging(t *testing.T) {
+	t.Parallel()
+	var page struct {
+		Paging fbapi.Paging `json:"paging"`
+	}
+	ensure.Nil(t, json.Unmarshal([]byte(`{"paging":{"cursors":{"before":"MQ","after":"Mg"}}}`), &page))
+	v, err := fbapi.ParamValues(fbapi.ParamLimit(1), fbapi.ParamAfter(page.Paging.Cursors.After))
+	ensure.Nil(t, err)
+	ensure.DeepEqual(t, v.Encode(), "after=Mg&limit=1")
+}
+
+func TestRawMessageResult(t *testing.T) {
+	t.Parallel()
+	const body = `{"data":[{"id":"1","type":"status"},{"id":"2","type":"photo"}]}`
+	c := &fbapi.Client{
+		Transport: fTransport(func(r *http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(strings.NewReader(body)),
+			}, nil
+		}),
+	}
+	var raw json.RawMessage
+	_, err := c.Do(&http.Request{Method: "GET"}, &raw)
+	ensure.Nil(t, err)
+	ensure.DeepEqual(t, string(raw), body)
+
+	var items []json.RawMessage
+	ensure.Nil(t, fbapi.DecodeData(raw, &items))
+	ensure.DeepEqual(t, items, []json.RawMessage{
+		json.RawMessage(`{"id":"1","type":"status"}`),
+		json.RawMessage(`{"id":"2","type":"photo"}`),
+	})
+}
+
+func TestRawMessageSliceResult(t *testing.T) {
+	t.Parallel()
+	c := &fbapi.Client{
+		Transport: fTransport(func(r *http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(strings.NewReader(`[{"code":200},null]`)),
+			}, nil
+		}),
+	}
+	var items []json.RawMessage
+	_, err := c.Do(&http.Request{Method: "GET"}, &items)
+	ensure.Nil(t, err)
+	ensure.DeepEqual(t, items, []json.RawMessage{
+		json.RawMessage(`{"code":200}`),
+		json.RawMessage(`null`),
+	})
+}
+
+func TestDecodeData(t *testing.T) {
+	t.Parallel()
+	var items []struct {
+		ID string `json:"id"`
+	}
+	ensure.Nil(t, fbapi.DecodeData([]byte(`{"data":[{"id":"1"}],"paging":{}}`), &items))
+	ensure.DeepEqual(t, len(items), 1)
+	ensure.DeepEqual(t, items[0].ID, "1")
+}
+
+func TestDecodeDataMissing(t *testing.T) {
+	t.Parallel()
+	var items []json.RawMessage
+	err := fbapi.DecodeData([]byte(`{"id":"1"}`), &items)
+	ensure.Err(t, err, regexp.MustCompile("response has no data"))
+	ensure.NotNil(t, fbapi.DecodeData([]byte(`[]`), &items))
+}
+
+func TestGetEdge(t *testing.T) {
+	t.Parallel()
+	c := &fbapi.Client{
+		Transport: fTransport(func(r *http.Request) (*http.Response, error) {
+			ensure.DeepEqual(t, r.URL.Path, "/me/friends")
+			ensure.DeepEqual(t, r.URL.Query().Get("limit"), "2")
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body: ioutil.NopCloser(strings.NewReader(`{
+					"data": [{"id": "1"}, {"id": "2"}],
+					"paging": {"next": "https://graph.facebook.com/me/friends?after=Mg"}
+				}`)),
+			}, nil
+		}),
+	}
+	var friends []struct {
+		ID string `json:"id"`
+	}
+	paging, err := c.GetEdge(context.Background(), "me/friends", &friends, fbapi.ParamLimit(2))
+	ensure.Nil(t, err)
+	ensure.DeepEqual(t, len(friends), 2)
+	ensure.DeepEqual(t, friends[1].ID, "2")
+	ensure.DeepEqual(t, paging, &fbapi.Paging{Next: "https://graph.facebook.com/me/friends?after=Mg"})
+}
+
+func TestGetEdgeSingleObject(t *testing.T) {
+	t.Parallel()
+	c := &fbapi.Client{
+		Transport: fTransport(func(r *http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(strings.NewReader(`{"id":"1","name":"Me"}`)),
+			}, nil
+		}),
+	}
+	var data []json.RawMessage
+	paging, err := c.GetEdge(context.Background(), "me", &data)
+	ensure.Err(t, err, regexp.MustCompile("response has no data"))
+	ensure.True(t, paging == nil)
+}
+
+func TestDecodeSummary(t *testing.T) {
+	t.Parallel()
+	s, err := fbapi.DecodeSummary([]byte(`{"data":[],"summary":{"order":"ranked","total_count":1234}}`))
+	ensure.Nil(t, err)
+	ensure.DeepEqual(t, s, &fbapi.Summary{TotalCount: 1234})
+
+	s, err = fbapi.DecodeSummary([]byte(`{"data":[]}`))
+	ensure.Nil(t, err)
+	ensure.True(t, s == nil)
+
+	_, err = fbapi.DecodeSummary([]byte(`[]`))
+	ensure.NotNil(t, err)
+}
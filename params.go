@@ -1,6 +1,8 @@
 package fbapi
 
 import (
+	"encoding/json"
+	"errors"
 	"net/url"
 	"strconv"
 	"strings"
@@ -23,6 +25,19 @@ func ParamValues(params ...Param) (v url.Values, err error) {
 	return v, nil
 }
 
+// Values is a Param setting arbitrary values, replacing any existing values for
+// the same keys. It is useful for passing prebuilt url.Values along with other
+// Params.
+type Values url.Values
+
+// Set the values.
+func (p Values) Set(values url.Values) error {
+	for key, v := range p {
+		values[key] = append([]string(nil), v...)
+	}
+	return nil
+}
+
 type paramLimit uint64
 
 func (p paramLimit) Set(v url.Values) error {
@@ -35,6 +50,33 @@ func ParamLimit(limit uint64) Param {
 	return paramLimit(limit)
 }
 
+var errZeroLimit = errors.New("fbapi: limit is 0, which returns no items or is ignored by the API")
+
+type paramLimitCapped struct {
+	limit uint64
+	max   uint64
+}
+
+func (p paramLimitCapped) Set(v url.Values) error {
+	if p.limit == 0 {
+		return errZeroLimit
+	}
+	limit := p.limit
+	if p.max != 0 && limit > p.max {
+		limit = p.max
+	}
+	v.Add("limit", strconv.FormatUint(limit, 10))
+	return nil
+}
+
+// ParamLimitCapped specifies a limit clamped to max, which is typically the
+// maximum page size of the edge. Larger limits are silently capped or rejected
+// by the API depending on the edge. A max of 0 means no cap. Unlike
+// ParamLimit, a limit of 0 is an error as it is rarely intended.
+func ParamLimitCapped(limit, max uint64) Param {
+	return paramLimitCapped{limit: limit, max: max}
+}
+
 type paramOffset uint64
 
 func (p paramOffset) Set(v url.Values) error {
@@ -53,17 +95,167 @@ func ParamOffset(offset uint64) Param {
 type paramFields []string
 
 func (p paramFields) Set(values url.Values) error {
-	if len(p) > 0 {
-		values.Set("fields", strings.Join(p, ","))
+	if len(p) == 0 {
+		return nil
+	}
+	fields := splitFields(values.Get("fields"))
+	seen := make(map[string]bool, len(fields)+len(p))
+	for _, f := range fields {
+		seen[f] = true
+	}
+	for _, list := range p {
+		for _, f := range splitFields(list) {
+			if !seen[f] {
+				seen[f] = true
+				fields = append(fields, f)
+			}
+		}
+	}
+	if len(fields) > 0 {
+		values.Set("fields", strings.Join(fields, ","))
 	}
 	return nil
 }
 
-// ParamFields specifies the fields to include.
+// ParamFields specifies the fields to include. The fields are merged with
+// those of previous ParamFields, so ParamFields("a") and ParamFields("b", "a")
+// request fields=a,b.
 func ParamFields(fields ...string) Param {
 	return paramFields(fields)
 }
 
+type paramSummary []string
+
+func (p paramSummary) Set(values url.Values) error {
+	if len(p) == 0 {
+		values.Set("summary", "true")
+		return nil
+	}
+	values.Set("summary", strings.Join(p, ","))
+	return nil
+}
+
+// ParamSummary requests the summary of an edge, such as the total_count of
+// comments, which is returned along with the data, see Summary. When no
+// fields are given summary=true is sent, which includes the default summary
+// fields of the edge.
+func ParamSummary(fields ...string) Param {
+	return paramSummary(fields)
+}
+
+type paramList struct {
+	key    string
+	values []string
+}
+
+func (p paramList) Set(values url.Values) error {
+	if len(p.values) > 0 {
+		values.Set(p.key, strings.Join(p.values, ","))
+	}
+	return nil
+}
+
+// ParamList specifies a comma separated list of values for the given key, for
+// example the metric parameter for insights. Empty lists are not sent.
+func ParamList(key string, values ...string) Param {
+	return paramList{key: key, values: values}
+}
+
+type paramString struct {
+	key   string
+	value string
+}
+
+func (p paramString) Set(values url.Values) error {
+	if p.value != "" {
+		values.Set(p.key, p.value)
+	}
+	return nil
+}
+
+// ParamString specifies an arbitrary parameter, for example type or locale.
+// Empty values are not sent.
+func ParamString(key, value string) Param {
+	return paramString{key: key, value: value}
+}
+
+// ParamLocale specifies the locale of the response, such as fr_FR, used to
+// localize names and other text fields. Empty locales are not sent.
+func ParamLocale(locale string) Param {
+	return paramString{key: "locale", value: locale}
+}
+
+type paramAdd struct {
+	key   string
+	value string
+}
+
+func (p paramAdd) Set(values url.Values) error {
+	values.Add(p.key, p.value)
+	return nil
+}
+
+// ParamAdd adds a value for the given key, keeping any existing values, for
+// the rare endpoints expecting repeated keys. Unlike the other params, empty
+// values are also sent.
+func ParamAdd(key, value string) Param {
+	return paramAdd{key: key, value: value}
+}
+
+// ParamAfter specifies the after cursor, for example Paging.Cursors.After to
+// fetch the next page. Empty cursors are not sent.
+func ParamAfter(cursor string) Param {
+	return paramString{key: "after", value: cursor}
+}
+
+// ParamBefore specifies the before cursor, for example Paging.Cursors.Before
+// to fetch the previous page. Empty cursors are not sent.
+func ParamBefore(cursor string) Param {
+	return paramString{key: "before", value: cursor}
+}
+
+type paramInt struct {
+	key   string
+	value int64
+}
+
+func (p paramInt) Set(values url.Values) error {
+	values.Set(p.key, strconv.FormatInt(p.value, 10))
+	return nil
+}
+
+// ParamInt specifies an arbitrary integer parameter. Note, 0 values are also
+// sent.
+func ParamInt(key string, value int64) Param {
+	return paramInt{key: key, value: value}
+}
+
+type paramJSON struct {
+	key   string
+	value interface{}
+}
+
+func (p paramJSON) Set(values url.Values) error {
+	j, err := json.Marshal(p.value)
+	if err != nil {
+		return err
+	}
+	values.Set(p.key, string(j))
+	return nil
+}
+
+// ParamJSON specifies a parameter whose value is the JSON encoding of v, as
+// expected by the API for structured parameters such as targeting.
+func ParamJSON(key string, v interface{}) Param {
+	return paramJSON{key: key, value: v}
+}
+
+// ParamIDs specifies the ids parameter for fetching multiple objects in one
+// request. Empty lists are not sent.
+func ParamIDs(ids ...string) Param {
+	return paramList{key: "ids", values: ids}
+}
+
 type paramAccessToken string
 
 func (p paramAccessToken) Set(values url.Values) error {
@@ -78,6 +270,30 @@ func ParamAccessToken(token string) Param {
 	return paramAccessToken(token)
 }
 
+// TokenSource provides access tokens, for example short lived ones that are
+// periodically refreshed.
+type TokenSource interface {
+	Token() (string, error)
+}
+
+type paramTokenSource struct {
+	TokenSource
+}
+
+func (p paramTokenSource) Set(values url.Values) error {
+	token, err := p.Token()
+	if err != nil {
+		return err
+	}
+	return paramAccessToken(token).Set(values)
+}
+
+// ParamTokenSource specifies the access_token parameter using the current token
+// from the TokenSource.
+func ParamTokenSource(ts TokenSource) Param {
+	return paramTokenSource{TokenSource: ts}
+}
+
 type paramDateFormat string
 
 func (p paramDateFormat) Set(values url.Values) error {
@@ -95,3 +311,8 @@ func ParamDateFormat(format string) Param {
 // Sets the RFC 3339 format that Go expects when unmarshalling time.Time JSON
 // values.
 var DateFormat = ParamDateFormat(`Y-m-d\TH:i:s\Z`)
+
+// DateFormatUnix sets the format to Unix timestamps in seconds. These are
+// JSON numbers which a time.Time can't unmarshal, so time fields must be
+// UnixTime or int64 instead.
+var DateFormatUnix = ParamDateFormat("U")
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"net/url"
 	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/facebookgo/fbapi"
@@ -26,6 +27,26 @@ func TestParams(t *testing.T) {
 			Params:   []fbapi.Param{fbapi.ParamLimit(42)},
 			Expected: url.Values{"limit": []string{"42"}},
 		},
+		{
+			Params:   []fbapi.Param{fbapi.ParamLimitCapped(42, 100)},
+			Expected: url.Values{"limit": []string{"42"}},
+		},
+		{
+			Params:   []fbapi.Param{fbapi.ParamLimitCapped(100, 100)},
+			Expected: url.Values{"limit": []string{"100"}},
+		},
+		{
+			Params:   []fbapi.Param{fbapi.ParamLimitCapped(101, 100)},
+			Expected: url.Values{"limit": []string{"100"}},
+		},
+		{
+			Params:   []fbapi.Param{fbapi.ParamLimitCapped(5000, 0)},
+			Expected: url.Values{"limit": []string{"5000"}},
+		},
+		{
+			Params:   []fbapi.Param{fbapi.ParamLimitCapped(1, 1)},
+			Expected: url.Values{"limit": []string{"1"}},
+		},
 		{
 			Params:   []fbapi.Param{fbapi.ParamOffset(42)},
 			Expected: url.Values{"offset": []string{"42"}},
@@ -34,6 +55,115 @@ func TestParams(t *testing.T) {
 			Params:   []fbapi.Param{fbapi.ParamFields("abc", "def")},
 			Expected: url.Values{"fields": []string{"abc,def"}},
 		},
+		{
+			Params:   []fbapi.Param{fbapi.ParamFields("a"), fbapi.ParamFields("b", "a")},
+			Expected: url.Values{"fields": []string{"a,b"}},
+		},
+		{
+			Params: []fbapi.Param{
+				fbapi.ParamFields("id", "comments.limit(2){id,from}"),
+				fbapi.ParamFields("name, id", "comments.limit(2){id,from}", ""),
+			},
+			Expected: url.Values{"fields": []string{"id,comments.limit(2){id,from},name"}},
+		},
+		{
+			Params: []fbapi.Param{
+				fbapi.Values{"fields": {"id,name"}},
+				fbapi.ParamFields("name", "picture"),
+			},
+			Expected: url.Values{"fields": []string{"id,name,picture"}},
+		},
+		{
+			Params:   []fbapi.Param{fbapi.ParamFields("")},
+			Expected: url.Values{},
+		},
+		{
+			Params:   []fbapi.Param{fbapi.ParamList("metric", "page_views", "page_fans")},
+			Expected: url.Values{"metric": []string{"page_views,page_fans"}},
+		},
+		{
+			Params:   []fbapi.Param{fbapi.ParamList("period", "day")},
+			Expected: url.Values{"period": []string{"day"}},
+		},
+		{
+			Params:   []fbapi.Param{fbapi.ParamList("metric")},
+			Expected: url.Values{},
+		},
+		{
+			Params:   []fbapi.Param{fbapi.ParamSummary()},
+			Expected: url.Values{"summary": []string{"true"}},
+		},
+		{
+			Params: []fbapi.Param{
+				fbapi.ParamFields("id", "message"),
+				fbapi.ParamLimit(25),
+				fbapi.ParamSummary("total_count", "can_comment"),
+			},
+			Expected: url.Values{
+				"fields":  []string{"id,message"},
+				"limit":   []string{"25"},
+				"summary": []string{"total_count,can_comment"},
+			},
+		},
+		{
+			Params:   []fbapi.Param{fbapi.ParamString("locale", "en_US")},
+			Expected: url.Values{"locale": []string{"en_US"}},
+		},
+		{
+			Params:   []fbapi.Param{fbapi.ParamLocale("fr_FR")},
+			Expected: url.Values{"locale": []string{"fr_FR"}},
+		},
+		{
+			Params:   []fbapi.Param{fbapi.ParamLocale("")},
+			Expected: url.Values{},
+		},
+		{
+			Params:   []fbapi.Param{fbapi.ParamString("type", "")},
+			Expected: url.Values{},
+		},
+		{
+			Params: []fbapi.Param{
+				fbapi.ParamLimit(1),
+				fbapi.Values{"limit": {"2"}, "ids": {"1", "2"}},
+			},
+			Expected: url.Values{"limit": []string{"2"}, "ids": []string{"1", "2"}},
+		},
+		{
+			Params:   []fbapi.Param{fbapi.ParamIDs("1", "2")},
+			Expected: url.Values{"ids": []string{"1,2"}},
+		},
+		{
+			Params:   []fbapi.Param{fbapi.ParamIDs()},
+			Expected: url.Values{},
+		},
+		{
+			Params:   []fbapi.Param{fbapi.ParamAdd("ids", "1"), fbapi.ParamAdd("ids", "2")},
+			Expected: url.Values{"ids": []string{"1", "2"}},
+		},
+		{
+			Params:   []fbapi.Param{fbapi.ParamString("ids", "1"), fbapi.ParamAdd("ids", "")},
+			Expected: url.Values{"ids": []string{"1", ""}},
+		},
+		{
+			Params:   []fbapi.Param{fbapi.ParamAfter("MQ"), fbapi.ParamBefore("Mg")},
+			Expected: url.Values{"after": []string{"MQ"}, "before": []string{"Mg"}},
+		},
+		{
+			Params:   []fbapi.Param{fbapi.ParamAfter(""), fbapi.ParamBefore("")},
+			Expected: url.Values{},
+		},
+		{
+			Params:   []fbapi.Param{fbapi.ParamInt("metadata", 1), fbapi.ParamInt("since", -42)},
+			Expected: url.Values{"metadata": []string{"1"}, "since": []string{"-42"}},
+		},
+		{
+			Params:   []fbapi.Param{fbapi.ParamInt("width", 0)},
+			Expected: url.Values{"width": []string{"0"}},
+		},
+		{
+			Params:   []fbapi.Param{fbapi.ParamJSON("targeting", map[string][]string{"countries": {"US"}})},
+			Expected: url.Values{"targeting": []string{`{"countries":["US"]}`}},
+		},
 		{
 			Params:   []fbapi.Param{fbapi.ParamAccessToken("42")},
 			Expected: url.Values{"access_token": []string{"42"}},
@@ -42,6 +172,10 @@ func TestParams(t *testing.T) {
 			Params:   []fbapi.Param{fbapi.ParamDateFormat("42")},
 			Expected: url.Values{"date_format": []string{"42"}},
 		},
+		{
+			Params:   []fbapi.Param{fbapi.DateFormatUnix},
+			Expected: url.Values{"date_format": []string{"U"}},
+		},
 	}
 
 	for _, c := range cases {
@@ -64,3 +198,36 @@ func TestParamsError(t *testing.T) {
 		t.Fatalf("expected %s got %s", paramWithErrorMessage, err)
 	}
 }
+
+type staticTokenSource string
+
+func (s staticTokenSource) Token() (string, error) {
+	return string(s), nil
+}
+
+func TestParamTokenSource(t *testing.T) {
+	v, err := fbapi.ParamValues(fbapi.ParamTokenSource(staticTokenSource("42")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.Get("access_token") != "42" {
+		t.Fatalf("expected 42 got %s", v.Get("access_token"))
+	}
+}
+
+func TestParamJSONError(t *testing.T) {
+	_, err := fbapi.ParamValues(fbapi.ParamJSON("foo", make(chan int)))
+	if err == nil {
+		t.Fatal("was expecting error")
+	}
+}
+
+func TestParamLimitCappedZero(t *testing.T) {
+	_, err := fbapi.ParamValues(fbapi.ParamLimitCapped(0, 100))
+	if err == nil {
+		t.Fatal("was expecting error")
+	}
+	if !strings.Contains(err.Error(), "limit is 0") {
+		t.Fatalf("unexpected error %s", err)
+	}
+}
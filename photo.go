@@ -0,0 +1,34 @@
+package fbapi
+
+import (
+	"context"
+	"io"
+)
+
+// PhotoUpload is the result of uploading a photo. The PostID is only provided
+// when the photo is published to a feed.
+type PhotoUpload struct {
+	ID     string `json:"id"`
+	PostID string `json:"post_id"`
+}
+
+// UploadPhoto uploads the image read from r to the path, typically
+// /{id}/photos, as a multipart request along with the params, such as the
+// message. The image is streamed rather than buffered in memory, and the
+// filename is sent for it.
+func (c *Client) UploadPhoto(ctx context.Context, path string, r io.Reader, filename string, params ...Param) (*PhotoUpload, error) {
+	v, err := ParamValues(params...)
+	if err != nil {
+		return nil, err
+	}
+	req, err := newMultipartRequest(path, v, "source", filename, r)
+	if err != nil {
+		return nil, err
+	}
+
+	var photo PhotoUpload
+	if _, err := c.DoContext(ctx, req, &photo); err != nil {
+		return nil, err
+	}
+	return &photo, nil
+}
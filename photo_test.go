@@ -0,0 +1,80 @@
+package fbapi_test
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/facebookgo/ensure"
+	"github.com/facebookgo/fbapi"
+)
+
+func TestUploadPhoto(t *testing.T) {
+	t.Parallel()
+	const image = "\x89PNG\r\n\x1a\nimage"
+	c := &fbapi.Client{
+		AccessToken: "at",
+		Transport: fTransport(func(r *http.Request) (*http.Response, error) {
+			ensure.DeepEqual(t, r.Method, "POST")
+			ensure.DeepEqual(t, r.URL.Path, "/42/photos")
+			ensure.DeepEqual(t, r.URL.Query().Get("access_token"), "at")
+			ensure.StringContains(t, r.Header.Get("Content-Type"), "multipart/form-data; boundary=")
+			ensure.Nil(t, r.ParseMultipartForm(1024))
+			ensure.DeepEqual(t, r.FormValue("message"), "hello")
+			f, header, err := r.FormFile("source")
+			ensure.Nil(t, err)
+			ensure.DeepEqual(t, header.Filename, "cat.png")
+			b, err := ioutil.ReadAll(f)
+			ensure.Nil(t, err)
+			ensure.DeepEqual(t, string(b), image)
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(strings.NewReader(`{"id":"1","post_id":"42_1"}`)),
+			}, nil
+		}),
+	}
+	photo, err := c.UploadPhoto(context.Background(), "42/photos", strings.NewReader(image), "cat.png",
+		fbapi.ParamString("message", "hello"))
+	ensure.Nil(t, err)
+	ensure.DeepEqual(t, photo, &fbapi.PhotoUpload{ID: "1", PostID: "42_1"})
+}
+
+func TestUploadPhotoStreams(t *testing.T) {
+	t.Parallel()
+	// the image is only available once the request is being sent
+	pr, pw := io.Pipe()
+	c := &fbapi.Client{
+		Transport: fTransport(func(r *http.Request) (*http.Response, error) {
+			go func() {
+				pw.Write([]byte("image"))
+				pw.Close()
+			}()
+			ensure.Nil(t, r.ParseMultipartForm(1024))
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(strings.NewReader(`{"id":"1"}`)),
+			}, nil
+		}),
+	}
+	photo, err := c.UploadPhoto(context.Background(), "42/photos", pr, "image.jpg")
+	ensure.Nil(t, err)
+	ensure.DeepEqual(t, photo.ID, "1")
+}
+
+func TestUploadPhotoError(t *testing.T) {
+	t.Parallel()
+	c := &fbapi.Client{
+		Transport: fTransport(func(r *http.Request) (*http.Response, error) {
+			r.Body.Close()
+			return &http.Response{
+				StatusCode: http.StatusBadRequest,
+				Body:       ioutil.NopCloser(strings.NewReader(`{"error":{"code":324}}`)),
+			}, nil
+		}),
+	}
+	_, err := c.UploadPhoto(context.Background(), "42/photos", strings.NewReader("x"), "x.jpg")
+	ensure.DeepEqual(t, withoutResponse(err), &fbapi.Error{Code: 324})
+}
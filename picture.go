@@ -0,0 +1,43 @@
+package fbapi
+
+import "encoding/json"
+
+// Picture is a profile picture for use in results. Depending on the params the
+// API returns the picture field either as a URL string or as an object with
+// the URL and details in data, this decodes from both:
+//
+//	var user struct {
+//		Picture fbapi.Picture `json:"picture"`
+//	}
+//
+// Only the URL is available when decoding from a string.
+type Picture struct {
+	URL          string `json:"url"`
+	Width        int    `json:"width"`
+	Height       int    `json:"height"`
+	IsSilhouette bool   `json:"is_silhouette"`
+}
+
+// UnmarshalJSON decodes a URL string or an object with the picture in data.
+func (p *Picture) UnmarshalJSON(b []byte) error {
+	if string(b) == "null" {
+		return nil
+	}
+
+	var url string
+	if err := json.Unmarshal(b, &url); err == nil {
+		*p = Picture{URL: url}
+		return nil
+	}
+
+	// the type avoids recursing into this method
+	type picture Picture
+	var wrapper struct {
+		Data picture `json:"data"`
+	}
+	if err := json.Unmarshal(b, &wrapper); err != nil {
+		return err
+	}
+	*p = Picture(wrapper.Data)
+	return nil
+}
@@ -0,0 +1,49 @@
+package fbapi_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/facebookgo/ensure"
+	"github.com/facebookgo/fbapi"
+)
+
+func TestPictureString(t *testing.T) {
+	t.Parallel()
+	var user struct {
+		Picture fbapi.Picture `json:"picture"`
+	}
+	ensure.Nil(t, json.Unmarshal([]byte(`{"picture":"https://example.com/a.jpg"}`), &user))
+	ensure.DeepEqual(t, user.Picture, fbapi.Picture{URL: "https://example.com/a.jpg"})
+}
+
+func TestPictureObject(t *testing.T) {
+	t.Parallel()
+	var user struct {
+		Picture fbapi.Picture `json:"picture"`
+	}
+	const body = `{"picture":{"data":{` +
+		`"url":"https://example.com/a.jpg","width":50,"height":60,"is_silhouette":true}}}`
+	ensure.Nil(t, json.Unmarshal([]byte(body), &user))
+	ensure.DeepEqual(t, user.Picture, fbapi.Picture{
+		URL:          "https://example.com/a.jpg",
+		Width:        50,
+		Height:       60,
+		IsSilhouette: true,
+	})
+}
+
+func TestPictureNull(t *testing.T) {
+	t.Parallel()
+	var user struct {
+		Picture fbapi.Picture `json:"picture"`
+	}
+	ensure.Nil(t, json.Unmarshal([]byte(`{"picture":null}`), &user))
+	ensure.DeepEqual(t, user.Picture, fbapi.Picture{})
+}
+
+func TestPictureInvalid(t *testing.T) {
+	t.Parallel()
+	var p fbapi.Picture
+	ensure.NotNil(t, json.Unmarshal([]byte(`42`), &p))
+}
@@ -0,0 +1,75 @@
+package fbapi
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// PollUntil fetches path every interval until isDone returns true for the
+// response body, which is then returned. This is useful for waiting on
+// asynchronous jobs, such as ad report runs. Errors, including the context
+// being done, stop the polling.
+func (c *Client) PollUntil(
+	ctx context.Context,
+	path string,
+	isDone func(json.RawMessage) bool,
+	interval time.Duration,
+	params ...Param,
+) (json.RawMessage, error) {
+	for {
+		var body json.RawMessage
+		if _, err := c.get(ctx, path, &body, params...); err != nil {
+			return nil, err
+		}
+		if isDone(body) {
+			return body, nil
+		}
+
+		if err := sleep(ctx, interval); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// HasNewItems fetches the newest item of the edge at path and reports whether
+// it differs from last, the newest item seen by a previous call, returning the
+// newest item to store for the next call. Items are identified by their ID,
+// or the before cursor for edges whose items don't have one. This allows
+// cheaply checking for new items such as comments, only the first item is
+// fetched. The limit param is set by this method and id is added to the
+// requested fields. An empty edge is never considered to have new items.
+func (c *Client) HasNewItems(ctx context.Context, path, last string, params ...Param) (string, bool, error) {
+	params = append(params[:len(params):len(params)], ParamInt("limit", 1), ParamFields("id"))
+	var page struct {
+		Data []struct {
+			ID string `json:"id"`
+		} `json:"data"`
+		Paging *Paging `json:"paging"`
+	}
+	if _, err := c.get(ctx, path, &page, params...); err != nil {
+		return "", false, err
+	}
+	if len(page.Data) == 0 {
+		return last, false, nil
+	}
+
+	newest := page.Data[0].ID
+	if newest == "" && page.Paging != nil {
+		newest = page.Paging.Cursors.Before
+	}
+	return newest, newest != last, nil
+}
+
+// Sleep for d, or until the context is done in which case its error is
+// returned.
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,124 @@
+package fbapi_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/facebookgo/ensure"
+	"github.com/facebookgo/fbapi"
+)
+
+func isCompleted(body json.RawMessage) bool {
+	var job struct {
+		Status string `json:"async_status"`
+	}
+	return json.Unmarshal(body, &job) == nil && job.Status == "Job Completed"
+}
+
+func TestPollUntil(t *testing.T) {
+	t.Parallel()
+	var calls int
+	c := &fbapi.Client{
+		Transport: fTransport(func(r *http.Request) (*http.Response, error) {
+			calls++
+			ensure.DeepEqual(t, r.URL.String(), "https://graph.facebook.com/42?fields=async_status")
+			status := "Job Running"
+			if calls == 3 {
+				status = "Job Completed"
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body: ioutil.NopCloser(strings.NewReader(
+					fmt.Sprintf(`{"async_status":%q,"call":%d}`, status, calls))),
+			}, nil
+		}),
+	}
+	body, err := c.PollUntil(
+		context.Background(),
+		"42",
+		isCompleted,
+		time.Millisecond,
+		fbapi.ParamFields("async_status"),
+	)
+	ensure.Nil(t, err)
+	ensure.DeepEqual(t, calls, 3)
+	ensure.DeepEqual(t, string(body), `{"async_status":"Job Completed","call":3}`)
+}
+
+func TestPollUntilContextDone(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	c := &fbapi.Client{
+		Transport: fTransport(func(r *http.Request) (*http.Response, error) {
+			cancel()
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(strings.NewReader(`{"async_status":"Job Running"}`)),
+			}, nil
+		}),
+	}
+	_, err := c.PollUntil(ctx, "42", isCompleted, time.Hour)
+	ensure.True(t, err == context.Canceled, err)
+}
+
+var newestItemQuery = url.Values{
+	"fields": {"id"},
+	"limit":  {"1"},
+}
+
+func TestHasNewItemsAdvanced(t *testing.T) {
+	t.Parallel()
+	c := bodyClient(t, "/42/comments", newestItemQuery, `{"data":[{"id":"42_2"}],"paging":{"cursors":{"before":"Mg"}}}`)
+	newest, ok, err := c.HasNewItems(context.Background(), "42/comments", "42_1")
+	ensure.Nil(t, err)
+	ensure.True(t, ok)
+	ensure.DeepEqual(t, newest, "42_2")
+}
+
+func TestHasNewItemsUnchanged(t *testing.T) {
+	t.Parallel()
+	c := bodyClient(t, "/42/comments", newestItemQuery, `{"data":[{"id":"42_1"}],"paging":{"cursors":{"before":"MQ"}}}`)
+	newest, ok, err := c.HasNewItems(context.Background(), "42/comments", "42_1")
+	ensure.Nil(t, err)
+	ensure.False(t, ok)
+	ensure.DeepEqual(t, newest, "42_1")
+}
+
+func TestHasNewItemsCursor(t *testing.T) {
+	t.Parallel()
+	c := bodyClient(t, "/42/comments", newestItemQuery, `{"data":[{}],"paging":{"cursors":{"before":"Mg"}}}`)
+	newest, ok, err := c.HasNewItems(context.Background(), "42/comments", "MQ")
+	ensure.Nil(t, err)
+	ensure.True(t, ok)
+	ensure.DeepEqual(t, newest, "Mg")
+}
+
+func TestHasNewItemsEmpty(t *testing.T) {
+	t.Parallel()
+	c := bodyClient(t, "/42/comments", newestItemQuery, `{"data":[]}`)
+	newest, ok, err := c.HasNewItems(context.Background(), "42/comments", "42_1")
+	ensure.Nil(t, err)
+	ensure.False(t, ok)
+	ensure.DeepEqual(t, newest, "42_1")
+}
+
+func TestHasNewItemsError(t *testing.T) {
+	t.Parallel()
+	givenErr := errors.New("")
+	c := &fbapi.Client{
+		Transport: fTransport(func(r *http.Request) (*http.Response, error) {
+			return nil, givenErr
+		}),
+	}
+	_, ok, err := c.HasNewItems(context.Background(), "42/comments", "42_1")
+	ensure.True(t, err == givenErr, err)
+	ensure.False(t, ok)
+}
@@ -0,0 +1,74 @@
+package fbapi
+
+import (
+	"net/http"
+	"time"
+)
+
+// RequestInfo describes a request made by the Client and its outcome. Secrets
+// such as access tokens are redacted from the URL and error.
+type RequestInfo struct {
+	Method     string
+	URL        string
+	StatusCode int
+	Duration   time.Duration
+
+	// The trace ID of the request for Facebook support, from the response
+	// headers or error.
+	FBTraceID string
+
+	// The error from the round trip or in the response, if any.
+	Err error
+}
+
+// RequestLogger is used by the Client to trace requests.
+type RequestLogger interface {
+	LogRequest(info RequestInfo)
+}
+
+type nopRequestLogger struct{}
+
+func (nopRequestLogger) LogRequest(RequestInfo) {}
+
+// NopRequestLogger discards the requests.
+var NopRequestLogger RequestLogger = nopRequestLogger{}
+
+type stdRequestLogger struct {
+	logger Logger
+}
+
+func (l stdRequestLogger) LogRequest(info RequestInfo) {
+	if info.Err != nil {
+		l.logger.Printf("fbapi: %s %s %d %s fbtrace_id=%q: %s",
+			info.Method, info.URL, info.StatusCode, info.Duration, info.FBTraceID, info.Err)
+		return
+	}
+	l.logger.Printf("fbapi: %s %s %d %s fbtrace_id=%q",
+		info.Method, info.URL, info.StatusCode, info.Duration, info.FBTraceID)
+}
+
+// StdRequestLogger returns a RequestLogger printing a line per request to the
+// Logger, such as a *log.Logger.
+func StdRequestLogger(logger Logger) RequestLogger {
+	return stdRequestLogger{logger: logger}
+}
+
+// Log the request with its outcome to the RequestLogger.
+func (c *Client) logRequest(req *http.Request, res *http.Response, err error, d time.Duration) {
+	info := RequestInfo{
+		Method:   requestMethod(req),
+		URL:      redactURL(req.URL),
+		Duration: d,
+	}
+	if res != nil {
+		info.StatusCode = res.StatusCode
+		info.FBTraceID = res.Header.Get("X-FB-Trace-ID")
+	}
+	if err != nil {
+		info.Err = redactURLError(err, req.URL)
+		if apiErr, ok := err.(*Error); ok && apiErr.FBTraceID != "" {
+			info.FBTraceID = apiErr.FBTraceID
+		}
+	}
+	c.RequestLogger.LogRequest(info)
+}
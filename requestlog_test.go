@@ -0,0 +1,103 @@
+package fbapi_test
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/facebookgo/ensure"
+	"github.com/facebookgo/fbapi"
+)
+
+type recordingRequestLogger []fbapi.RequestInfo
+
+func (l *recordingRequestLogger) LogRequest(info fbapi.RequestInfo) {
+	*l = append(*l, info)
+}
+
+func TestRequestLogger(t *testing.T) {
+	t.Parallel()
+	var logged recordingRequestLogger
+	c := &fbapi.Client{
+		AccessToken:   "secret-token",
+		RequestLogger: &logged,
+		Transport: fTransport(func(r *http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Header:     http.Header{"X-Fb-Trace-Id": []string{"trace"}},
+				Body:       ioutil.NopCloser(strings.NewReader(`{}`)),
+			}, nil
+		}),
+	}
+	_, err := c.Do(&http.Request{Method: "GET", URL: &url.URL{Path: "me"}}, nil)
+	ensure.Nil(t, err)
+	ensure.DeepEqual(t, len(logged), 1)
+	ensure.DeepEqual(t, logged[0].Method, "GET")
+	ensure.DeepEqual(t, logged[0].StatusCode, http.StatusOK)
+	ensure.DeepEqual(t, logged[0].FBTraceID, "trace")
+	ensure.Nil(t, logged[0].Err)
+	ensure.StringContains(t, logged[0].URL, "/me?access_token=REDACTED")
+	ensure.StringDoesNotContain(t, logged[0].URL, "secret-token")
+}
+
+func TestRequestLoggerAPIError(t *testing.T) {
+	t.Parallel()
+	var logged recordingRequestLogger
+	transport, _ := sequenceTransport(t,
+		fakeResponse{Code: http.StatusServiceUnavailable, Body: `{"error":{"code":2,"fbtrace_id":"first"}}`},
+		fakeResponse{Code: http.StatusBadRequest, Body: `{"error":{"code":100,"fbtrace_id":"second"}}`},
+	)
+	c := &fbapi.Client{
+		Transport:     transport,
+		Retry:         fastRetry,
+		RequestLogger: &logged,
+	}
+	_, err := c.Do(&http.Request{Method: "GET"}, nil)
+	ensure.NotNil(t, err)
+	ensure.DeepEqual(t, len(logged), 2)
+	ensure.DeepEqual(t, logged[0].StatusCode, http.StatusServiceUnavailable)
+	ensure.DeepEqual(t, logged[0].FBTraceID, "first")
+	ensure.DeepEqual(t, logged[1].StatusCode, http.StatusBadRequest)
+	ensure.DeepEqual(t, logged[1].FBTraceID, "second")
+	ensure.DeepEqual(t, logged[1].Err, err)
+}
+
+func TestRequestLoggerTransportErrorRedacted(t *testing.T) {
+	t.Parallel()
+	var logged recordingRequestLogger
+	c := &fbapi.Client{
+		AccessToken:   "secret-token",
+		RequestLogger: &logged,
+		Transport: fTransport(func(r *http.Request) (*http.Response, error) {
+			return nil, &url.Error{Op: "Get", URL: r.URL.String(), Err: errors.New("connection reset")}
+		}),
+	}
+	_, err := c.Do(&http.Request{URL: &url.URL{Path: "me"}}, nil)
+	ensure.NotNil(t, err)
+	ensure.DeepEqual(t, len(logged), 1)
+	ensure.DeepEqual(t, logged[0].Method, "GET")
+	ensure.DeepEqual(t, logged[0].StatusCode, 0)
+	ensure.StringContains(t, logged[0].Err.Error(), "connection reset")
+	ensure.StringDoesNotContain(t, logged[0].Err.Error(), "secret-token")
+}
+
+func TestStdRequestLogger(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	l := fbapi.StdRequestLogger(log.New(&buf, "", 0))
+	l.LogRequest(fbapi.RequestInfo{
+		Method:     "GET",
+		URL:        "https://graph.facebook.com/me",
+		StatusCode: 400,
+		FBTraceID:  "trace",
+		Err:        errors.New("bad"),
+	})
+	ensure.DeepEqual(t, buf.String(),
+		"fbapi: GET https://graph.facebook.com/me 400 0s fbtrace_id=\"trace\": bad\n")
+	fbapi.NopRequestLogger.LogRequest(fbapi.RequestInfo{})
+}
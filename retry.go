@@ -0,0 +1,155 @@
+package fbapi
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultRetryMaxAttempts = 3
+	defaultRetryBaseDelay   = 100 * time.Millisecond
+	defaultRetryMaxDelay    = 10 * time.Second
+)
+
+// Retry configures retrying requests that failed with transient errors. These
+// are transport errors, 429, 500 and 503 responses and API errors with code 1
+// (unknown error) or 2 (service temporarily unavailable). A 429 is typically
+// returned by a proxy in front of the API rather than the API itself. Retries
+// are delayed using jittered exponential backoff, or as specified by the
+// Retry-After header when the response includes one. If all attempts fail the error from
+// the last one is returned.
+type Retry struct {
+	// Maximum number of attempts, including the first one. Defaults to 3.
+	MaxAttempts int
+
+	// The delay before the first retry, doubled for each following one.
+	// Defaults to 100ms.
+	BaseDelay time.Duration
+
+	// Maximum delay before a retry. Defaults to 10s.
+	MaxDelay time.Duration
+
+	// By default only GET and HEAD requests are retried. When true requests
+	// with any method are retried, in which case the request body is buffered
+	// in memory in order to be resent.
+	AllMethods bool
+}
+
+func (r *Retry) allows(method string) bool {
+	return r.AllMethods || method == "GET" || method == "HEAD" || method == ""
+}
+
+func (r *Retry) maxAttempts() int {
+	if r.MaxAttempts == 0 {
+		return defaultRetryMaxAttempts
+	}
+	return r.MaxAttempts
+}
+
+// Returns the response an API error was found in, or nil for other errors, to
+// check if an error from a call not returning the response is transient.
+func errorResponse(err error) *http.Response {
+	if apiErr, ok := err.(*Error); ok {
+		return apiErr.Response()
+	}
+	return nil
+}
+
+// Check if the result of an attempt is a transient failure.
+func transient(res *http.Response, err error) bool {
+	if err == nil {
+		return false
+	}
+	if apiErr, ok := err.(*Error); ok {
+		if apiErr.IsVersionDeprecated() {
+			return false
+		}
+		if apiErr.Code == 1 || apiErr.Code == 2 {
+			return true
+		}
+	}
+	if res == nil {
+		return true
+	}
+	return res.StatusCode == http.StatusTooManyRequests ||
+		res.StatusCode == http.StatusInternalServerError ||
+		res.StatusCode == http.StatusServiceUnavailable
+}
+
+// The delay before the given retry, starting at 1.
+func (r *Retry) delay(retry int, res *http.Response) time.Duration {
+	if d, ok := RetryAfter(res); ok {
+		return d
+	}
+
+	base := r.BaseDelay
+	if base == 0 {
+		base = defaultRetryBaseDelay
+	}
+	max := r.MaxDelay
+	if max == 0 {
+		max = defaultRetryMaxDelay
+	}
+	d := base << uint(retry-1)
+	if d > max || d <= 0 {
+		d = max
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+// RetryAfter returns the delay requested by the Retry-After header of the
+// response, which may be specified in seconds or as an HTTP-date. Dates in the
+// past result in a zero delay. The returned bool is false if the response is
+// nil or the header is missing or invalid.
+func RetryAfter(res *http.Response) (time.Duration, bool) {
+	if res == nil {
+		return 0, false
+	}
+	v := res.Header.Get("Retry-After")
+	if v == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.ParseUint(v, 10, 32); err == nil {
+		return time.Duration(seconds) * time.Second, true
+	}
+	date, err := http.ParseTime(v)
+	if err != nil {
+		return 0, false
+	}
+	d := date.Sub(time.Now())
+	if d < 0 {
+		d = 0
+	}
+	return d, true
+}
+
+// Perform a prepared request, retrying transient failures.
+func (c *Client) doRetry(ctx context.Context, req *http.Request, result interface{}) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for attempt := 1; ; attempt++ {
+		if req.Body != nil {
+			req.Body = ioutil.NopCloser(bytes.NewReader(body))
+		}
+		res, err := c.do(ctx, req, result)
+		if attempt >= c.Retry.maxAttempts() || ctx.Err() != nil || !transient(res, err) {
+			return res, err
+		}
+		if err := sleep(ctx, c.Retry.delay(attempt, res)); err != nil {
+			return nil, err
+		}
+	}
+}
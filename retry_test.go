@@ -0,0 +1,214 @@
+package fbapi_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/facebookgo/ensure"
+	"github.com/facebookgo/fbapi"
+)
+
+type fakeResponse struct {
+	Code   int
+	Body   string
+	Header http.Header
+	Err    error
+}
+
+// Returns a transport which returns the given responses in order, and a
+// pointer to the number of requests made.
+func sequenceTransport(t *testing.T, responses ...fakeResponse) (http.RoundTripper, *int) {
+	var calls int
+	return fTransport(func(r *http.Request) (*http.Response, error) {
+		if calls >= len(responses) {
+			t.Fatalf("unexpected request %d", calls+1)
+		}
+		fr := responses[calls]
+		calls++
+		if fr.Err != nil {
+			return nil, fr.Err
+		}
+		return &http.Response{
+			StatusCode: fr.Code,
+			Header:     fr.Header,
+			Body:       ioutil.NopCloser(strings.NewReader(fr.Body)),
+		}, nil
+	}), &calls
+}
+
+var fastRetry = &fbapi.Retry{
+	MaxAttempts: 3,
+	BaseDelay:   time.Millisecond,
+	MaxDelay:    time.Millisecond,
+}
+
+func TestRetryTransientStatus(t *testing.T) {
+	t.Parallel()
+	transport, calls := sequenceTransport(t,
+		fakeResponse{Code: http.StatusServiceUnavailable, Body: `{"error":{}}`},
+		fakeResponse{Err: errors.New("connection reset")},
+		fakeResponse{Code: http.StatusOK, Body: `{"id":"42"}`},
+	)
+	c := &fbapi.Client{Transport: transport, Retry: fastRetry}
+	var actual map[string]string
+	_, err := c.Do(&http.Request{Method: "GET"}, &actual)
+	ensure.Nil(t, err)
+	ensure.DeepEqual(t, *calls, 3)
+	ensure.DeepEqual(t, actual, map[string]string{"id": "42"})
+}
+
+func TestRetryTransientCode(t *testing.T) {
+	t.Parallel()
+	transport, calls := sequenceTransport(t,
+		fakeResponse{Code: http.StatusBadRequest, Body: `{"error":{"code":2}}`},
+		fakeResponse{Code: http.StatusOK, Body: `{}`},
+	)
+	c := &fbapi.Client{Transport: transport, Retry: fastRetry}
+	_, err := c.Do(&http.Request{Method: "GET"}, nil)
+	ensure.Nil(t, err)
+	ensure.DeepEqual(t, *calls, 2)
+}
+
+func TestRetryPermanentError(t *testing.T) {
+	t.Parallel()
+	for _, body := range []string{`{"error":{"code":100}}`, `{"error":{"code":2635}}`} {
+		transport, calls := sequenceTransport(t,
+			fakeResponse{Code: http.StatusBadRequest, Body: body},
+		)
+		c := &fbapi.Client{Transport: transport, Retry: fastRetry}
+		_, err := c.Do(&http.Request{Method: "GET"}, nil)
+		ensure.NotNil(t, err)
+		ensure.DeepEqual(t, *calls, 1)
+	}
+}
+
+func TestRetryAllAttemptsFail(t *testing.T) {
+	t.Parallel()
+	transport, calls := sequenceTransport(t,
+		fakeResponse{Code: http.StatusInternalServerError, Body: `{"error":{"code":1,"message":"first"}}`},
+		fakeResponse{Code: http.StatusInternalServerError, Body: `{"error":{"code":1,"message":"second"}}`},
+		fakeResponse{Code: http.StatusInternalServerError, Body: `{"error":{"code":1,"message":"last"}}`},
+	)
+	c := &fbapi.Client{Transport: transport, Retry: fastRetry}
+	_, err := c.Do(&http.Request{Method: "GET"}, nil)
+	ensure.DeepEqual(t, withoutResponse(err), &fbapi.Error{Code: 1, Message: "last"})
+	ensure.DeepEqual(t, *calls, 3)
+}
+
+func TestRetryPostNotRetriedByDefault(t *testing.T) {
+	t.Parallel()
+	transport, calls := sequenceTransport(t,
+		fakeResponse{Code: http.StatusServiceUnavailable, Body: `{"error":{"code":2}}`},
+	)
+	c := &fbapi.Client{Transport: transport, Retry: fastRetry}
+	req, err := http.NewRequest("POST", "me/feed", strings.NewReader("message=hello"))
+	ensure.Nil(t, err)
+	_, err = c.Do(req, nil)
+	ensure.DeepEqual(t, withoutResponse(err), &fbapi.Error{Code: 2})
+	ensure.DeepEqual(t, *calls, 1)
+}
+
+func TestRetryPostAllMethods(t *testing.T) {
+	t.Parallel()
+	var bodies []string
+	transport, calls := sequenceTransport(t,
+		fakeResponse{Code: http.StatusServiceUnavailable, Body: `{"error":{"code":2}}`},
+		fakeResponse{Code: http.StatusOK, Body: `{"id":"1"}`},
+	)
+	c := &fbapi.Client{
+		Transport: fTransport(func(r *http.Request) (*http.Response, error) {
+			b, err := ioutil.ReadAll(r.Body)
+			ensure.Nil(t, err)
+			bodies = append(bodies, string(b))
+			return transport.RoundTrip(r)
+		}),
+		Retry: &fbapi.Retry{
+			BaseDelay:  time.Millisecond,
+			AllMethods: true,
+		},
+	}
+	req, err := http.NewRequest("POST", "me/feed", strings.NewReader("message=hello"))
+	ensure.Nil(t, err)
+	_, err = c.Do(req, nil)
+	ensure.Nil(t, err)
+	ensure.DeepEqual(t, *calls, 2)
+	ensure.DeepEqual(t, bodies, []string{"message=hello", "message=hello"})
+}
+
+func TestRetryAfterHeader(t *testing.T) {
+	t.Parallel()
+	transport, calls := sequenceTransport(t,
+		fakeResponse{
+			Code:   http.StatusServiceUnavailable,
+			Body:   `{"error":{}}`,
+			Header: http.Header{"Retry-After": []string{"0"}},
+		},
+		fakeResponse{Code: http.StatusOK, Body: `{}`},
+	)
+	// the backoff delay would time out the test if the header was ignored
+	c := &fbapi.Client{
+		Transport: transport,
+		Retry:     &fbapi.Retry{BaseDelay: time.Hour, MaxDelay: time.Hour},
+	}
+	_, err := c.Do(&http.Request{Method: "GET"}, nil)
+	ensure.Nil(t, err)
+	ensure.DeepEqual(t, *calls, 2)
+}
+
+func TestRetryTooManyRequests(t *testing.T) {
+	t.Parallel()
+	transport, calls := sequenceTransport(t,
+		fakeResponse{
+			Code:   http.StatusTooManyRequests,
+			Body:   `<html>slow down</html>`,
+			Header: http.Header{"Retry-After": []string{time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat)}},
+		},
+		fakeResponse{Code: http.StatusOK, Body: `{}`},
+	)
+	c := &fbapi.Client{
+		Transport: transport,
+		Retry:     &fbapi.Retry{BaseDelay: time.Hour, MaxDelay: time.Hour},
+	}
+	_, err := c.Do(&http.Request{Method: "GET"}, nil)
+	ensure.Nil(t, err)
+	ensure.DeepEqual(t, *calls, 2)
+}
+
+func TestRetryAfterSeconds(t *testing.T) {
+	t.Parallel()
+	d, ok := fbapi.RetryAfter(&http.Response{Header: http.Header{"Retry-After": []string{"120"}}})
+	ensure.True(t, ok)
+	ensure.DeepEqual(t, d, 2*time.Minute)
+}
+
+func TestRetryAfterDate(t *testing.T) {
+	t.Parallel()
+	date := time.Now().Add(time.Hour).UTC().Format(http.TimeFormat)
+	d, ok := fbapi.RetryAfter(&http.Response{Header: http.Header{"Retry-After": []string{date}}})
+	ensure.True(t, ok)
+	// the date has a resolution of a second
+	ensure.True(t, d > time.Hour-2*time.Second && d <= time.Hour, d)
+}
+
+func TestRetryAfterPastDate(t *testing.T) {
+	t.Parallel()
+	d, ok := fbapi.RetryAfter(&http.Response{
+		Header: http.Header{"Retry-After": []string{"Wed, 21 Oct 2015 07:28:00 GMT"}},
+	})
+	ensure.True(t, ok)
+	ensure.DeepEqual(t, d, time.Duration(0))
+}
+
+func TestRetryAfterInvalid(t *testing.T) {
+	t.Parallel()
+	for _, h := range []http.Header{nil, {"Retry-After": []string{"soon"}}, {"Retry-After": []string{"-1"}}} {
+		_, ok := fbapi.RetryAfter(&http.Response{Header: h})
+		ensure.False(t, ok)
+	}
+	_, ok := fbapi.RetryAfter(nil)
+	ensure.False(t, ok)
+}
@@ -0,0 +1,65 @@
+package fbapi
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+var (
+	// ErrMalformedSignedRequest is returned by ParseSignedRequest when the
+	// signed request can't be decoded.
+	ErrMalformedSignedRequest = errors.New("fbapi: malformed signed request")
+
+	// ErrInvalidSignature is returned by ParseSignedRequest when the signature
+	// doesn't match the payload, for example because it was signed with
+	// another app secret or was tampered with.
+	ErrInvalidSignature = errors.New("fbapi: invalid signed request signature")
+
+	// ErrUnsupportedAlgorithm is returned by ParseSignedRequest when the
+	// payload was signed using an algorithm other than HMAC-SHA256.
+	ErrUnsupportedAlgorithm = errors.New("fbapi: unsupported signed request algorithm")
+)
+
+// ParseSignedRequest verifies and decodes the payload of a signed_request, as
+// provided to Canvas apps and by some login flows. It consists of the
+// signature and the JSON payload, both base64url encoded and separated by a
+// dot, the signature being the HMAC-SHA256 of the encoded payload keyed by the
+// app secret.
+func ParseSignedRequest(signedRequest, appSecret string) (map[string]interface{}, error) {
+	parts := strings.SplitN(signedRequest, ".", 2)
+	if len(parts) != 2 {
+		return nil, ErrMalformedSignedRequest
+	}
+	sig, err := decodeBase64URL(parts[0])
+	if err != nil {
+		return nil, ErrMalformedSignedRequest
+	}
+	rawPayload, err := decodeBase64URL(parts[1])
+	if err != nil {
+		return nil, ErrMalformedSignedRequest
+	}
+
+	var payload map[string]interface{}
+	if err := json.Unmarshal(rawPayload, &payload); err != nil || payload == nil {
+		return nil, ErrMalformedSignedRequest
+	}
+	if algorithm, _ := payload["algorithm"].(string); strings.ToUpper(algorithm) != "HMAC-SHA256" {
+		return nil, ErrUnsupportedAlgorithm
+	}
+
+	mac := hmac.New(sha256.New, []byte(appSecret))
+	mac.Write([]byte(parts[1]))
+	if !hmac.Equal(sig, mac.Sum(nil)) {
+		return nil, ErrInvalidSignature
+	}
+	return payload, nil
+}
+
+// Decode base64url with or without padding.
+func decodeBase64URL(s string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+}
@@ -0,0 +1,71 @@
+package fbapi_test
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/facebookgo/ensure"
+	"github.com/facebookgo/fbapi"
+)
+
+func signRequest(payload, secret string) string {
+	encoded := base64.RawURLEncoding.EncodeToString([]byte(payload))
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(encoded))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil)) + "." + encoded
+}
+
+func TestParseSignedRequest(t *testing.T) {
+	t.Parallel()
+	signed := signRequest(`{"algorithm":"HMAC-SHA256","issued_at":1291836800,"user_id":"42"}`, "s3cret")
+	payload, err := fbapi.ParseSignedRequest(signed, "s3cret")
+	ensure.Nil(t, err)
+	ensure.DeepEqual(t, payload, map[string]interface{}{
+		"algorithm": "HMAC-SHA256",
+		"issued_at": float64(1291836800),
+		"user_id":   "42",
+	})
+}
+
+func TestParseSignedRequestPadded(t *testing.T) {
+	t.Parallel()
+	// the 32 byte signature is padded by some encoders
+	signed := signRequest(`{"algorithm":"HMAC-SHA256"}`, "s3cret")
+	signed = strings.Replace(signed, ".", "=.", 1)
+	_, err := fbapi.ParseSignedRequest(signed, "s3cret")
+	ensure.Nil(t, err)
+}
+
+func TestParseSignedRequestInvalidSignature(t *testing.T) {
+	t.Parallel()
+	signed := signRequest(`{"algorithm":"HMAC-SHA256","user_id":"42"}`, "other")
+	_, err := fbapi.ParseSignedRequest(signed, "s3cret")
+	ensure.True(t, err == fbapi.ErrInvalidSignature, err)
+}
+
+func TestParseSignedRequestUnsupportedAlgorithm(t *testing.T) {
+	t.Parallel()
+	for _, payload := range []string{`{"algorithm":"HMAC-SHA1"}`, `{}`} {
+		_, err := fbapi.ParseSignedRequest(signRequest(payload, "s3cret"), "s3cret")
+		ensure.True(t, err == fbapi.ErrUnsupportedAlgorithm, err)
+	}
+}
+
+func TestParseSignedRequestMalformed(t *testing.T) {
+	t.Parallel()
+	payload := base64.RawURLEncoding.EncodeToString([]byte(`{"algorithm":"HMAC-SHA256"}`))
+	for _, signed := range []string{
+		"",
+		"nodot",
+		"!!!." + payload,
+		"c2ln.!!!",
+		"c2ln." + base64.RawURLEncoding.EncodeToString([]byte(`not json`)),
+		"c2ln." + base64.RawURLEncoding.EncodeToString([]byte(`null`)),
+	} {
+		_, err := fbapi.ParseSignedRequest(signed, "s3cret")
+		ensure.True(t, err == fbapi.ErrMalformedSignedRequest, signed, err)
+	}
+}
@@ -0,0 +1,51 @@
+package fbapi
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+)
+
+// Success is a result for requests such as updates and deletes, whose
+// responses are either true or {"success":true}:
+//
+//	var ok fbapi.Success
+//	if _, err := client.Do(req, &ok); err != nil {
+//		return err
+//	}
+//	if !ok.OK {
+//		// not applied
+//	}
+//
+// A *bool result is decoded the same way. For both an error object in a
+// successful response is returned as an error.
+type Success struct {
+	OK bool
+}
+
+// UnmarshalJSON decodes a boolean or an object with a success boolean.
+func (s *Success) UnmarshalJSON(b []byte) error {
+	if err := json.Unmarshal(b, &s.OK); err == nil {
+		return nil
+	}
+	var object struct {
+		Success bool `json:"success"`
+	}
+	if err := json.Unmarshal(b, &object); err != nil {
+		return err
+	}
+	s.OK = object.Success
+	return nil
+}
+
+// Decode the Success in r, returning any error found at the errorPath.
+func unmarshalSuccess(r io.Reader, s *Success, errorPath string) error {
+	body, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	if apiError := findError(body, errorPath); apiError != nil {
+		return apiError
+	}
+	return json.Unmarshal(body, s)
+}
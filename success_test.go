@@ -0,0 +1,75 @@
+package fbapi_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/facebookgo/ensure"
+	"github.com/facebookgo/fbapi"
+)
+
+func TestSuccess(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		Body     string
+		Expected bool
+	}{
+		{Body: `true`, Expected: true},
+		{Body: `false`, Expected: false},
+		{Body: `{"success":true}`, Expected: true},
+		{Body: `{"success":false}`, Expected: false},
+	}
+	for _, tc := range cases {
+		var ok fbapi.Success
+		_, err := bodyClient(t, "", nil, tc.Body).Do(&http.Request{Method: "DELETE"}, &ok)
+		ensure.Nil(t, err)
+		ensure.DeepEqual(t, ok.OK, tc.Expected, tc.Body)
+
+		var b bool
+		_, err = bodyClient(t, "", nil, tc.Body).Do(&http.Request{Method: "DELETE"}, &b)
+		ensure.Nil(t, err)
+		ensure.DeepEqual(t, b, tc.Expected, tc.Body)
+	}
+}
+
+func TestSuccessErrorInSuccessfulResponse(t *testing.T) {
+	t.Parallel()
+	const body = `{"success":false,"error":{"code":100,"message":"partial failure"}}`
+	expected := &fbapi.Error{Code: 100, Message: "partial failure"}
+
+	var ok fbapi.Success
+	_, err := bodyClient(t, "", nil, body).Do(&http.Request{Method: "POST"}, &ok)
+	ensure.DeepEqual(t, withoutResponse(err), expected)
+
+	var b bool
+	_, err = bodyClient(t, "", nil, body).Do(&http.Request{Method: "POST"}, &b)
+	ensure.DeepEqual(t, withoutResponse(err), expected)
+
+	_, err = bodyClient(t, "", nil, body).Do(&http.Request{Method: "POST"}, nil)
+	ensure.DeepEqual(t, withoutResponse(err), expected)
+}
+
+func TestSuccessErrorFieldNotAnError(t *testing.T) {
+	t.Parallel()
+	const body = `{"id":"1","success":true,"error":{"reason":"none"}}`
+
+	var ok fbapi.Success
+	_, err := bodyClient(t, "", nil, body).Do(&http.Request{Method: "POST"}, &ok)
+	ensure.Nil(t, err)
+	ensure.True(t, ok.OK)
+
+	_, err = bodyClient(t, "", nil, body).Do(&http.Request{Method: "POST"}, nil)
+	ensure.Nil(t, err)
+
+	var m map[string]interface{}
+	_, err = bodyClient(t, "", nil, body).Do(&http.Request{Method: "POST"}, &m)
+	ensure.Nil(t, err)
+	ensure.DeepEqual(t, m["id"], "1")
+}
+
+func TestSuccessInvalid(t *testing.T) {
+	t.Parallel()
+	var ok fbapi.Success
+	_, err := bodyClient(t, "", nil, `"yes"`).Do(&http.Request{Method: "POST"}, &ok)
+	ensure.NotNil(t, err)
+}
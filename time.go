@@ -0,0 +1,51 @@
+package fbapi
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// The format used by the API for times when no date_format is specified.
+const defaultTimeFormat = "2006-01-02T15:04:05-0700"
+
+// UnixTime is a time.Time for use in results which decodes from the integer
+// seconds returned by the API when DateFormatUnix is used. A plain
+// time.Time can't decode those, so pair that param with this type:
+//
+//	var post struct {
+//		CreatedTime fbapi.UnixTime `json:"created_time"`
+//	}
+//
+// For convenience it also decodes the RFC 3339 strings returned when using
+// DateFormat and the API's default format, so results don't need to change
+// along with the date_format.
+type UnixTime struct {
+	time.Time
+}
+
+// UnmarshalJSON decodes integer seconds or a time string.
+func (t *UnixTime) UnmarshalJSON(b []byte) error {
+	if string(b) == "null" {
+		return nil
+	}
+
+	var seconds int64
+	if err := json.Unmarshal(b, &seconds); err == nil {
+		t.Time = time.Unix(seconds, 0).UTC()
+		return nil
+	}
+
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	parsed, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		parsed, err = time.Parse(defaultTimeFormat, s)
+		if err != nil {
+			return err
+		}
+	}
+	t.Time = parsed
+	return nil
+}
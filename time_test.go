@@ -0,0 +1,85 @@
+package fbapi_test
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/facebookgo/ensure"
+	"github.com/facebookgo/fbapi"
+)
+
+func TestUnixTimeDateFormatU(t *testing.T) {
+	t.Parallel()
+	c := &fbapi.Client{
+		Transport: fTransport(func(r *http.Request) (*http.Response, error) {
+			ensure.DeepEqual(t, r.URL.Query().Get("date_format"), "U")
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body: ioutil.NopCloser(strings.NewReader(
+					`{"id":"1","created_time":1262304000,"updated_time":null}`)),
+			}, nil
+		}),
+	}
+	v, err := fbapi.ParamValues(fbapi.ParamDateFormat("U"))
+	ensure.Nil(t, err)
+	var post struct {
+		ID          string         `json:"id"`
+		CreatedTime fbapi.UnixTime `json:"created_time"`
+		UpdatedTime fbapi.UnixTime `json:"updated_time"`
+	}
+	_, err = c.Do(&http.Request{
+		Method: "GET",
+		URL:    &url.URL{Path: "1", RawQuery: v.Encode()},
+	}, &post)
+	ensure.Nil(t, err)
+	ensure.DeepEqual(t, post.CreatedTime.Time, time.Date(2010, 1, 1, 0, 0, 0, 0, time.UTC))
+	ensure.True(t, post.UpdatedTime.IsZero())
+}
+
+func TestUnixTimeStrings(t *testing.T) {
+	t.Parallel()
+	expected := time.Date(2010, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, s := range []string{`"2010-01-01T00:00:00Z"`, `"2010-01-01T00:00:00+0000"`} {
+		var actual fbapi.UnixTime
+		ensure.Nil(t, json.Unmarshal([]byte(s), &actual))
+		ensure.True(t, actual.Equal(expected), s, actual)
+	}
+}
+
+func TestUnixTimeInvalid(t *testing.T) {
+	t.Parallel()
+	var actual fbapi.UnixTime
+	ensure.NotNil(t, json.Unmarshal([]byte(`"yesterday"`), &actual))
+	ensure.NotNil(t, json.Unmarshal([]byte(`true`), &actual))
+}
+
+func TestDateFormatUnixInt64(t *testing.T) {
+	t.Parallel()
+	c := &fbapi.Client{
+		Transport: fTransport(func(r *http.Request) (*http.Response, error) {
+			ensure.DeepEqual(t, r.URL.Query().Get("date_format"), "U")
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(strings.NewReader(`{"created_time":1262304000}`)),
+			}, nil
+		}),
+	}
+	var post struct {
+		CreatedTime int64 `json:"created_time"`
+	}
+	_, err := c.Get(context.Background(), "1", &post, fbapi.DateFormatUnix)
+	ensure.Nil(t, err)
+	ensure.DeepEqual(t, post.CreatedTime, int64(1262304000))
+
+	var wrong struct {
+		CreatedTime time.Time `json:"created_time"`
+	}
+	_, err = c.Get(context.Background(), "1", &wrong, fbapi.DateFormatUnix)
+	ensure.NotNil(t, err)
+}
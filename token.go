@@ -0,0 +1,114 @@
+package fbapi
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+	"time"
+)
+
+var errInvalidToken = errors.New("fbapi: token is not valid")
+
+// TokenInfo is the information about an access token provided by DebugToken.
+type TokenInfo struct {
+	AppID       string
+	Type        string
+	Application string
+	UserID      string
+	IsValid     bool
+	Scopes      []string
+
+	// Zero if the token never expires.
+	ExpiresAt time.Time
+
+	// Zero if not provided, as is the case for some token types.
+	IssuedAt time.Time
+
+	// Explains why the token isn't valid, nil otherwise.
+	Error *Error
+}
+
+// DebugToken inspects the inputToken using /debug_token, authenticated by the
+// appToken, or by the Client access token if appToken is empty. Invalid tokens
+// are not an error, the TokenInfo is returned with IsValid false.
+func (c *Client) DebugToken(ctx context.Context, inputToken, appToken string) (*TokenInfo, error) {
+	var res struct {
+		Data struct {
+			AppID       string   `json:"app_id"`
+			Type        string   `json:"type"`
+			Application string   `json:"application"`
+			UserID      string   `json:"user_id"`
+			IsValid     bool     `json:"is_valid"`
+			Scopes      []string `json:"scopes"`
+			ExpiresAt   int64    `json:"expires_at"`
+			IssuedAt    int64    `json:"issued_at"`
+			Error       *Error   `json:"error"`
+		} `json:"data"`
+	}
+	path := "debug_token?" + (url.Values{"input_token": []string{inputToken}}).Encode()
+	if _, err := c.get(ctx, path, &res, ParamAccessToken(appToken)); err != nil {
+		return nil, err
+	}
+
+	data := res.Data
+	info := &TokenInfo{
+		AppID:       data.AppID,
+		Type:        data.Type,
+		Application: data.Application,
+		UserID:      data.UserID,
+		IsValid:     data.IsValid,
+		Scopes:      data.Scopes,
+		Error:       data.Error,
+	}
+	if data.ExpiresAt != 0 {
+		info.ExpiresAt = time.Unix(data.ExpiresAt, 0).UTC()
+	}
+	if data.IssuedAt != 0 {
+		info.IssuedAt = time.Unix(data.IssuedAt, 0).UTC()
+	}
+	return info, nil
+}
+
+// MissingScopesError is returned by RequireScopes when a token is missing some
+// of the required scopes.
+type MissingScopesError struct {
+	Scopes []string
+}
+
+func (e *MissingScopesError) Error() string {
+	return "fbapi: token is missing required scopes: " + strings.Join(e.Scopes, ", ")
+}
+
+// RequireScopes checks that the token is valid and has been granted all the
+// required scopes, returning a *MissingScopesError listing any that are
+// missing. The token is inspected using DebugToken, authenticated by the
+// Client access token, or by the token itself if the Client has none.
+func (c *Client) RequireScopes(ctx context.Context, token string, required []string) error {
+	var appToken string
+	if c.AccessToken == "" && c.TokenSource == nil {
+		appToken = token
+	}
+	info, err := c.DebugToken(ctx, token, appToken)
+	if err != nil {
+		return err
+	}
+	if !info.IsValid {
+		return errInvalidToken
+	}
+
+	granted := make(map[string]bool, len(info.Scopes))
+	for _, scope := range info.Scopes {
+		granted[scope] = true
+	}
+	var missing []string
+	for _, scope := range required {
+		if !granted[scope] {
+			missing = append(missing, scope)
+		}
+	}
+	if len(missing) != 0 {
+		return &MissingScopesError{Scopes: missing}
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package fbapi_test
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/facebookgo/ensure"
+	"github.com/facebookgo/fbapi"
+)
+
+var debugTokenQuery = url.Values{
+	"input_token":  {"user-token"},
+	"access_token": {"user-token"},
+}
+
+const debugTokenBody = `{
+	"data": {
+		"app_id": "42",
+		"type": "USER",
+		"application": "App",
+		"expires_at": 1352419328,
+		"is_valid": true,
+		"issued_at": 1347235328,
+		"scopes": ["email", "public_profile", "pages_show_list"],
+		"user_id": "1"
+	}
+}`
+
+func TestRequireScopes(t *testing.T) {
+	t.Parallel()
+	c := bodyClient(t, "/debug_token", debugTokenQuery, debugTokenBody)
+	ensure.Nil(t, c.RequireScopes(context.Background(), "user-token", []string{"email", "pages_show_list"}))
+}
+
+func TestRequireScopesMissing(t *testing.T) {
+	t.Parallel()
+	c := bodyClient(t, "/debug_token", debugTokenQuery, debugTokenBody)
+	err := c.RequireScopes(context.Background(), "user-token", []string{"email", "publish_pages", "manage_pages"})
+	ensure.DeepEqual(t, err, &fbapi.MissingScopesError{
+		Scopes: []string{"publish_pages", "manage_pages"},
+	})
+	ensure.DeepEqual(t, err.Error(),
+		"fbapi: token is missing required scopes: publish_pages, manage_pages")
+}
+
+func TestRequireScopesInvalidToken(t *testing.T) {
+	t.Parallel()
+	c := bodyClient(t, "/debug_token", debugTokenQuery, `{"data":{"is_valid":false,"scopes":[]}}`)
+	err := c.RequireScopes(context.Background(), "user-token", nil)
+	ensure.DeepEqual(t, err.Error(), "fbapi: token is not valid")
+}
+
+func TestDebugToken(t *testing.T) {
+	t.Parallel()
+	c := bodyClient(t, "/debug_token", debugTokenQuery, debugTokenBody)
+	info, err := c.DebugToken(context.Background(), "user-token", "user-token")
+	ensure.Nil(t, err)
+	ensure.DeepEqual(t, info, &fbapi.TokenInfo{
+		AppID:       "42",
+		Type:        "USER",
+		Application: "App",
+		UserID:      "1",
+		IsValid:     true,
+		Scopes:      []string{"email", "public_profile", "pages_show_list"},
+		ExpiresAt:   time.Unix(1352419328, 0).UTC(),
+		IssuedAt:    time.Unix(1347235328, 0).UTC(),
+	})
+}
+
+func TestDebugTokenNeverExpires(t *testing.T) {
+	t.Parallel()
+	c := bodyClient(t, "/debug_token", debugTokenQuery, `{"data":{"type":"PAGE","expires_at":0,"is_valid":true}}`)
+	info, err := c.DebugToken(context.Background(), "user-token", "user-token")
+	ensure.Nil(t, err)
+	ensure.True(t, info.IsValid)
+	ensure.True(t, info.ExpiresAt.IsZero(), info.ExpiresAt)
+}
+
+func TestDebugTokenInvalid(t *testing.T) {
+	t.Parallel()
+	c := bodyClient(t, "/debug_token", debugTokenQuery, `{"data":{
+		"is_valid": false,
+		"error": {"code": 190, "subcode": 463, "message": "Session has expired"}
+	}}`)
+	info, err := c.DebugToken(context.Background(), "user-token", "user-token")
+	ensure.Nil(t, err)
+	ensure.False(t, info.IsValid)
+	ensure.DeepEqual(t, info.Error, &fbapi.Error{Code: 190, Message: "Session has expired"})
+}
+
+func TestDebugTokenClientAccessToken(t *testing.T) {
+	t.Parallel()
+	c := &fbapi.Client{
+		AccessToken: "app-token",
+		Transport: fTransport(func(r *http.Request) (*http.Response, error) {
+			ensure.DeepEqual(t, r.URL.Query().Get("input_token"), "user-token")
+			ensure.DeepEqual(t, r.URL.Query().Get("access_token"), "app-token")
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(strings.NewReader(debugTokenBody)),
+			}, nil
+		}),
+	}
+	info, err := c.DebugToken(context.Background(), "user-token", "")
+	ensure.Nil(t, err)
+	ensure.True(t, info.IsValid)
+}
+
+func TestDebugTokenError(t *testing.T) {
+	t.Parallel()
+	c := &fbapi.Client{
+		Transport: fTransport(func(r *http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: http.StatusBadRequest,
+				Body:       ioutil.NopCloser(strings.NewReader(`{"error":{"code":100}}`)),
+			}, nil
+		}),
+	}
+	_, err := c.DebugToken(context.Background(), "user-token", "app-token")
+	ensure.DeepEqual(t, withoutResponse(err), &fbapi.Error{Code: 100})
+}
@@ -0,0 +1,113 @@
+package fbapi
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// Records the timing breakdown of a single request.
+type timingTrace struct {
+	stats Stats
+	start time.Time
+
+	mu           sync.Mutex
+	dnsStart     time.Time
+	connectStart map[string]time.Time
+	tlsStart     time.Time
+}
+
+func (t *timingTrace) record(name string, start time.Time) {
+	if start.IsZero() {
+		return
+	}
+	t.stats.Record(name, float64(time.Since(start))/float64(time.Millisecond))
+}
+
+func (t *timingTrace) clientTrace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) {
+			t.mu.Lock()
+			t.dnsStart = time.Now()
+			t.mu.Unlock()
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.record("timing.dns", t.dnsStart)
+		},
+		ConnectStart: func(network, addr string) {
+			t.mu.Lock()
+			t.connectStart[network+addr] = time.Now()
+			t.mu.Unlock()
+		},
+		ConnectDone: func(network, addr string, err error) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			if err == nil {
+				t.record("timing.connect", t.connectStart[network+addr])
+			}
+		},
+		TLSHandshakeStart: func() {
+			t.mu.Lock()
+			t.tlsStart = time.Now()
+			t.mu.Unlock()
+		},
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.record("timing.tls", t.tlsStart)
+		},
+		GotFirstResponseByte: func() {
+			t.record("timing.first_byte", t.start)
+		},
+	}
+}
+
+// Returns the request with a trace recording the DNS, connect, TLS and time to
+// first byte timings to the Stats.
+func (c *Client) withTimingTrace(req *http.Request) *http.Request {
+	t := &timingTrace{
+		stats:        c.Stats,
+		start:        time.Now(),
+		connectStart: make(map[string]time.Time),
+	}
+	ctx := httptrace.WithClientTrace(req.Context(), t.clientTrace())
+	return req.WithContext(ctx)
+}
+
+// Returns the method of the request, an empty one meaning GET as for net/http.
+func requestMethod(req *http.Request) string {
+	if req.Method == "" {
+		return "GET"
+	}
+	return req.Method
+}
+
+// Record the count, duration and outcome of a request to the Stats.
+func (c *Client) recordRequest(req *http.Request, res *http.Response, err error, d time.Duration) {
+	prefix := "request." + requestMethod(req)
+	c.Stats.Inc(prefix)
+	c.Stats.Record(prefix+".time", float64(d)/float64(time.Millisecond))
+	if res != nil {
+		c.Stats.Inc(fmt.Sprintf("%s.status.%d", prefix, res.StatusCode))
+	}
+	if err == nil || err == ErrNotModified {
+		return
+	}
+	errType := "transport"
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		errType = apiErr.Type
+		if errType == "" {
+			errType = "unknown"
+		}
+	} else if res != nil {
+		errType = "response"
+	}
+	c.Stats.Inc(prefix + ".error." + errType)
+}
@@ -0,0 +1,125 @@
+package fbapi_test
+
+import (
+	"crypto/tls"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptrace"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/facebookgo/ensure"
+	"github.com/facebookgo/fbapi"
+)
+
+type recordingStats struct {
+	mu       sync.Mutex
+	counts   map[string]int
+	recorded map[string][]float64
+}
+
+func newRecordingStats() *recordingStats {
+	return &recordingStats{
+		counts:   make(map[string]int),
+		recorded: make(map[string][]float64),
+	}
+}
+
+func (s *recordingStats) Inc(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.counts[name]++
+}
+
+func (s *recordingStats) Record(name string, value float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.recorded[name] = append(s.recorded[name], value)
+}
+
+func TestTraceTiming(t *testing.T) {
+	t.Parallel()
+	stats := newRecordingStats()
+	c := &fbapi.Client{
+		Stats:       stats,
+		TraceTiming: true,
+		Transport: fTransport(func(r *http.Request) (*http.Response, error) {
+			trace := httptrace.ContextClientTrace(r.Context())
+			ensure.NotNil(t, trace)
+			trace.DNSStart(httptrace.DNSStartInfo{Host: "graph.facebook.com"})
+			time.Sleep(time.Millisecond)
+			trace.DNSDone(httptrace.DNSDoneInfo{})
+			trace.ConnectStart("tcp", "127.0.0.1:443")
+			time.Sleep(time.Millisecond)
+			trace.ConnectDone("tcp", "127.0.0.1:443", nil)
+			trace.TLSHandshakeStart()
+			time.Sleep(time.Millisecond)
+			trace.TLSHandshakeDone(tls.ConnectionState{}, nil)
+			trace.GotFirstResponseByte()
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(strings.NewReader("{}")),
+			}, nil
+		}),
+	}
+	_, err := c.Do(&http.Request{Method: "GET"}, nil)
+	ensure.Nil(t, err)
+	for _, name := range []string{"timing.dns", "timing.connect", "timing.tls"} {
+		ensure.DeepEqual(t, len(stats.recorded[name]), 1, name)
+		ensure.True(t, stats.recorded[name][0] >= 1, name, stats.recorded[name])
+	}
+	ensure.DeepEqual(t, len(stats.recorded["timing.first_byte"]), 1)
+	ensure.True(t, stats.recorded["timing.first_byte"][0] >= 3)
+}
+
+func TestTraceTimingDisabled(t *testing.T) {
+	t.Parallel()
+	c := &fbapi.Client{
+		Stats: newRecordingStats(),
+		Transport: fTransport(func(r *http.Request) (*http.Response, error) {
+			ensure.True(t, httptrace.ContextClientTrace(r.Context()) == nil)
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(strings.NewReader("{}")),
+			}, nil
+		}),
+	}
+	_, err := c.Do(&http.Request{Method: "GET"}, nil)
+	ensure.Nil(t, err)
+}
+
+func TestRequestStats(t *testing.T) {
+	t.Parallel()
+	stats := newRecordingStats()
+	transport, _ := sequenceTransport(t,
+		fakeResponse{Code: http.StatusOK, Body: `{}`},
+		fakeResponse{Code: http.StatusBadRequest, Body: `{"error":{"type":"OAuthException","code":190}}`},
+		fakeResponse{Code: http.StatusInternalServerError, Body: `{"error":{"code":1}}`},
+		fakeResponse{Err: errors.New("connection reset")},
+	)
+	c := &fbapi.Client{Stats: stats, Transport: transport}
+	_, err := c.Do(&http.Request{Method: "GET"}, nil)
+	ensure.Nil(t, err)
+	_, err = c.Do(&http.Request{Method: "POST"}, nil)
+	ensure.NotNil(t, err)
+	_, err = c.Do(&http.Request{Method: "GET"}, nil)
+	ensure.NotNil(t, err)
+	_, err = c.Do(&http.Request{}, nil)
+	ensure.NotNil(t, err)
+
+	ensure.DeepEqual(t, stats.counts, map[string]int{
+		"request.GET":                       3,
+		"request.GET.status.200":            1,
+		"request.GET.status.500":            1,
+		"request.GET.error.unknown":         1,
+		"request.GET.error.transport":       1,
+		"request.POST":                      1,
+		"request.POST.status.400":           1,
+		"request.POST.error.OAuthException": 1,
+	})
+	ensure.DeepEqual(t, len(stats.recorded["request.GET.time"]), 3)
+	ensure.DeepEqual(t, len(stats.recorded["request.POST.time"]), 1)
+}
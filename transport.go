@@ -0,0 +1,218 @@
+package fbapi
+
+import (
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Middleware wraps a http.RoundTripper to add behavior to it. Middlewares
+// compose, for example:
+//
+//	client := &fbapi.Client{
+//		Transport: fbapi.Chain(http.DefaultTransport,
+//			fbapi.TokenInjector(token),
+//			fbapi.LoggingTransport(logger),
+//		),
+//	}
+//
+// They are alternatives to the equivalent Client options for use with other
+// http clients, or to control the order in which they apply.
+type Middleware func(http.RoundTripper) http.RoundTripper
+
+// Chain wraps the base http.RoundTripper with the middlewares, the first one
+// being the outermost. A nil base means http.DefaultTransport.
+func Chain(base http.RoundTripper, middlewares ...Middleware) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		base = middlewares[i](base)
+	}
+	return base
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+// Returns the RoundTripper, or http.DefaultTransport if it is nil.
+func orDefaultTransport(rt http.RoundTripper) http.RoundTripper {
+	if rt == nil {
+		return http.DefaultTransport
+	}
+	return rt
+}
+
+// Returns a middleware setting the auth params as the Client would.
+func authMiddleware(c *Client) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		next = orDefaultTransport(next)
+		return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			// the request must not be modified, the copy gets its own URL
+			req = req.WithContext(req.Context())
+			if err := c.setAuthParams(req); err != nil {
+				return nil, err
+			}
+			return next.RoundTrip(req)
+		})
+	}
+}
+
+// TokenInjector returns a middleware setting the access_token parameter to the
+// token, unless the request already includes one.
+func TokenInjector(token string) Middleware {
+	return authMiddleware(&Client{AccessToken: token})
+}
+
+// AppSecretProofInjector returns a middleware setting the appsecret_proof
+// parameter for requests with an access_token parameter, unless already
+// included. It must be applied after the token is set, so it should come
+// after TokenInjector in a Chain.
+func AppSecretProofInjector(secret string) Middleware {
+	return authMiddleware(&Client{AppSecret: secret})
+}
+
+// RetryTransport returns a middleware retrying requests as configured by the
+// Retry, which may be nil to use the defaults. Unlike the Client Retry, it
+// can't see the API error codes, so transient failures are identified by the
+// transport errors and status codes alone. Requests with a body are only
+// retried if they have GetBody set, as is the case for those made by
+// http.NewRequest with common body types.
+func RetryTransport(r *Retry) Middleware {
+	if r == nil {
+		r = &Retry{}
+	}
+	return func(next http.RoundTripper) http.RoundTripper {
+		next = orDefaultTransport(next)
+		return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			if !r.allows(req.Method) || (req.Body != nil && req.GetBody == nil) {
+				return next.RoundTrip(req)
+			}
+			ctx := req.Context()
+			for attempt := 1; ; attempt++ {
+				attemptReq := req
+				if attempt > 1 && req.Body != nil {
+					body, err := req.GetBody()
+					if err != nil {
+						return nil, err
+					}
+					attemptReq = req.WithContext(ctx)
+					attemptReq.Body = body
+				}
+				res, err := next.RoundTrip(attemptReq)
+				if attempt >= r.maxAttempts() || ctx.Err() != nil || !transientStatus(res, err) {
+					return res, err
+				}
+				delay := r.delay(attempt, res)
+				if res != nil {
+					res.Body.Close()
+				}
+				if err := sleep(ctx, delay); err != nil {
+					return nil, err
+				}
+			}
+		})
+	}
+}
+
+// Check if the result of a round trip is a transient failure based on the
+// error and status code alone.
+func transientStatus(res *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	return res.StatusCode == http.StatusTooManyRequests ||
+		res.StatusCode == http.StatusInternalServerError ||
+		res.StatusCode == http.StatusServiceUnavailable
+}
+
+// LoggingTransport returns a middleware logging each request with its status
+// code or error and duration. Secrets such as tokens are redacted from the
+// logged URL and errors.
+func LoggingTransport(logger Logger) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		next = orDefaultTransport(next)
+		return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			start := time.Now()
+			res, err := next.RoundTrip(req)
+			elapsed := time.Since(start)
+			u := redactURL(req.URL)
+			if err != nil {
+				logger.Printf("fbapi: %s %s failed after %s: %s",
+					req.Method, u, elapsed, redactURLError(err, req.URL))
+				return res, err
+			}
+			logger.Printf("fbapi: %s %s %d %s", req.Method, u, res.StatusCode, elapsed)
+			return res, err
+		})
+	}
+}
+
+const redacted = "REDACTED"
+
+// RedactToken returns a placeholder for a non empty token, to be used in place
+// of the token when formatting values holding one.
+func RedactToken(token string) string {
+	if token == "" {
+		return ""
+	}
+	return redacted
+}
+
+// The query parameters which hold secrets.
+var secretParams = []string{
+	"access_token",
+	"appsecret_proof",
+	"client_secret",
+	"fb_exchange_token",
+	"input_token",
+}
+
+// Returns the URL with the secret query parameters redacted.
+func redactURL(u *url.URL) string {
+	query := u.Query()
+	var changed bool
+	for _, key := range secretParams {
+		if values, ok := query[key]; ok {
+			for i := range values {
+				values[i] = redacted
+			}
+			changed = true
+		}
+	}
+	if !changed {
+		return u.String()
+	}
+	v := *u
+	v.RawQuery = query.Encode()
+	return v.String()
+}
+
+// Returns a shallow copy of the request with secrets redacted from its URL.
+// The body is dropped as it may include secrets and has been sent already.
+func redactRequest(req *http.Request) *http.Request {
+	r := req.WithContext(req.Context())
+	r.Body = nil
+	r.GetBody = nil
+	if req.URL != nil {
+		if u, err := url.Parse(redactURL(req.URL)); err == nil {
+			r.URL = u
+		}
+	}
+	return r
+}
+
+// Returns err with the values of the secret query parameters of the URL
+// redacted from its message.
+func redactURLError(err error, u *url.URL) error {
+	query := u.Query()
+	for _, key := range secretParams {
+		for _, value := range query[key] {
+			err = redactError(err, value)
+		}
+	}
+	return err
+}
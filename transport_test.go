@@ -0,0 +1,113 @@
+package fbapi_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/facebookgo/ensure"
+	"github.com/facebookgo/fbapi"
+)
+
+func TestChainTokenAndProof(t *testing.T) {
+	t.Parallel()
+	base := fTransport(func(r *http.Request) (*http.Response, error) {
+		q := r.URL.Query()
+		ensure.DeepEqual(t, q.Get("access_token"), "token")
+		ensure.DeepEqual(t, q.Get("appsecret_proof"), fbapi.AppSecretProof("token", "secret"))
+		return nil, errors.New("done")
+	})
+	rt := fbapi.Chain(base, fbapi.TokenInjector("token"), fbapi.AppSecretProofInjector("secret"))
+	req, err := http.NewRequest("GET", "https://graph.facebook.com/me", nil)
+	ensure.Nil(t, err)
+	_, err = rt.RoundTrip(req)
+	ensure.Err(t, err, regexp.MustCompile("done"))
+	// the request itself is left as is
+	ensure.DeepEqual(t, req.URL.String(), "https://graph.facebook.com/me")
+}
+
+func TestTokenInjectorKeepsExplicitToken(t *testing.T) {
+	t.Parallel()
+	base := fTransport(func(r *http.Request) (*http.Response, error) {
+		ensure.DeepEqual(t, r.URL.Query().Get("access_token"), "explicit")
+		return nil, errors.New("done")
+	})
+	req, err := http.NewRequest("GET", "https://graph.facebook.com/me?access_token=explicit", nil)
+	ensure.Nil(t, err)
+	_, err = fbapi.TokenInjector("token")(base).RoundTrip(req)
+	ensure.NotNil(t, err)
+}
+
+func TestRetryTransport(t *testing.T) {
+	t.Parallel()
+	var bodies []string
+	transport, calls := sequenceTransport(t,
+		fakeResponse{Code: http.StatusServiceUnavailable, Body: `{"error":{}}`},
+		fakeResponse{Err: errors.New("connection reset")},
+		fakeResponse{Code: http.StatusOK, Body: `{"id":"42"}`},
+	)
+	recording := fTransport(func(r *http.Request) (*http.Response, error) {
+		b, err := ioutil.ReadAll(r.Body)
+		ensure.Nil(t, err)
+		bodies = append(bodies, string(b))
+		return transport.RoundTrip(r)
+	})
+	retry := &fbapi.Retry{AllMethods: true, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond}
+	c := &fbapi.Client{Transport: fbapi.RetryTransport(retry)(recording)}
+	req, err := http.NewRequest("POST", "https://graph.facebook.com/me/feed", strings.NewReader("message=hi"))
+	ensure.Nil(t, err)
+	var actual map[string]string
+	_, err = c.Do(req, &actual)
+	ensure.Nil(t, err)
+	ensure.DeepEqual(t, *calls, 3)
+	ensure.DeepEqual(t, bodies, []string{"message=hi", "message=hi", "message=hi"})
+	ensure.DeepEqual(t, actual, map[string]string{"id": "42"})
+}
+
+func TestRetryTransportPermanent(t *testing.T) {
+	t.Parallel()
+	transport, calls := sequenceTransport(t,
+		fakeResponse{Code: http.StatusBadRequest, Body: `{"error":{"code":100}}`},
+	)
+	c := &fbapi.Client{Transport: fbapi.RetryTransport(nil)(transport)}
+	_, err := c.Do(&http.Request{Method: "GET"}, nil)
+	ensure.DeepEqual(t, withoutResponse(err), &fbapi.Error{Code: 100})
+	ensure.DeepEqual(t, *calls, 1)
+}
+
+func TestLoggingTransportRedacts(t *testing.T) {
+	t.Parallel()
+	logger := &recordingLogger{}
+	transport, _ := sequenceTransport(t,
+		fakeResponse{Code: http.StatusOK, Body: `{}`},
+		fakeResponse{Err: errors.New("failed https://graph.facebook.com/me?access_token=s3cret")},
+	)
+	c := &fbapi.Client{
+		AccessToken: "s3cret",
+		AppSecret:   "app-secret",
+		Transport:   fbapi.LoggingTransport(logger)(transport),
+	}
+	_, err := c.Do(&http.Request{Method: "GET"}, nil)
+	ensure.Nil(t, err)
+	_, err = c.Do(&http.Request{Method: "GET"}, nil)
+	ensure.NotNil(t, err)
+
+	ensure.DeepEqual(t, len(logger.lines), 2)
+	ensure.StringContains(t, logger.lines[0],
+		"fbapi: GET https://graph.facebook.com/?access_token=REDACTED&appsecret_proof=REDACTED 200 ")
+	ensure.StringContains(t, logger.lines[1], "failed after")
+	ensure.StringContains(t, logger.lines[1], "failed https://graph.facebook.com/me?access_token=REDACTED")
+	for _, line := range logger.lines {
+		ensure.False(t, strings.Contains(line, "s3cret"), line)
+		ensure.False(t, strings.Contains(line, fbapi.AppSecretProof("s3cret", "app-secret")), line)
+	}
+}
+
+func TestChainDefaultBase(t *testing.T) {
+	t.Parallel()
+	ensure.True(t, fbapi.Chain(nil) == http.DefaultTransport)
+}
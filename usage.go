@@ -0,0 +1,176 @@
+package fbapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	defaultBudgetThreshold = 75
+	defaultBudgetMaxDelay  = time.Minute
+	defaultBudgetWindow    = time.Minute
+)
+
+// AppUsage is the application rate limit usage reported by the API in the
+// X-App-Usage header, in percent of the limits.
+type AppUsage struct {
+	CallCount    int `json:"call_count"`
+	TotalTime    int `json:"total_time"`
+	TotalCPUTime int `json:"total_cputime"`
+}
+
+// Max returns the highest of the usage percentages.
+func (u *AppUsage) Max() int {
+	max := u.CallCount
+	if u.TotalTime > max {
+		max = u.TotalTime
+	}
+	if u.TotalCPUTime > max {
+		max = u.TotalCPUTime
+	}
+	return max
+}
+
+// ParseAppUsage parses the X-App-Usage header of a response. It returns nil
+// without an error if the header is missing. A malformed header results in an
+// error, but note the Client never fails a request because of it.
+func ParseAppUsage(res *http.Response) (*AppUsage, error) {
+	v := res.Header.Get("X-App-Usage")
+	if v == "" {
+		return nil, nil
+	}
+	var u AppUsage
+	if err := json.Unmarshal([]byte(v), &u); err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+type usageObservation struct {
+	at    time.Time
+	usage int
+}
+
+// BudgetTracker tracks the application rate limit usage reported in responses
+// in order to pace calls, for example in bulk jobs, as app rate limits can take
+// hours to recover from once reached. Set it as the Client Budget to have it
+// observe all responses, and share it between Clients using the same app. The
+// zero value is ready to use.
+type BudgetTracker struct {
+	// Usage percentage after which a delay is suggested. Defaults to 75.
+	Threshold int
+
+	// The delay suggested at 100% usage. The delay increases linearly from
+	// zero at the Threshold. Defaults to 1 minute.
+	MaxDelay time.Duration
+
+	// The usage is the worst case of the observations made within the Window.
+	// This accounts for responses to concurrent requests arriving out of
+	// order. Defaults to 1 minute.
+	Window time.Duration
+
+	// When true, the Client waits for the Delay before sending each request.
+	// Otherwise the Delay is only suggested.
+	Wait bool
+
+	mu           sync.Mutex
+	observations []usageObservation
+	retryUntil   time.Time
+}
+
+// Observe the X-App-Usage and Retry-After headers of the response, if any.
+// Missing or malformed headers are ignored.
+func (b *BudgetTracker) Observe(res *http.Response) {
+	now := time.Now()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if d, ok := RetryAfter(res); ok {
+		if until := now.Add(d); until.After(b.retryUntil) {
+			b.retryUntil = until
+		}
+	}
+	u, err := ParseAppUsage(res)
+	if err != nil || u == nil {
+		return
+	}
+	b.expire(now)
+	b.observations = append(b.observations, usageObservation{at: now, usage: u.Max()})
+}
+
+// Drop observations outside the window. The lock must be held.
+func (b *BudgetTracker) expire(now time.Time) {
+	window := b.Window
+	if window == 0 {
+		window = defaultBudgetWindow
+	}
+	i := 0
+	for i < len(b.observations) && now.Sub(b.observations[i].at) > window {
+		i++
+	}
+	b.observations = b.observations[i:]
+}
+
+// Usage returns the current worst case usage percentage, or zero if nothing
+// was observed recently.
+func (b *BudgetTracker) Usage() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.expire(time.Now())
+	var max int
+	for _, o := range b.observations {
+		if o.usage > max {
+			max = o.usage
+		}
+	}
+	return max
+}
+
+// Delay returns the suggested delay before the next call based on the current
+// Usage, or the time left before the one requested by the last Retry-After
+// header if longer.
+func (b *BudgetTracker) Delay() time.Duration {
+	b.mu.Lock()
+	retryAfter := time.Until(b.retryUntil)
+	b.mu.Unlock()
+	if d := b.usageDelay(); d > retryAfter {
+		return d
+	}
+	if retryAfter < 0 {
+		return 0
+	}
+	return retryAfter
+}
+
+// Returns the delay based on the current Usage.
+func (b *BudgetTracker) usageDelay() time.Duration {
+	threshold := b.Threshold
+	if threshold == 0 {
+		threshold = defaultBudgetThreshold
+	}
+	maxDelay := b.MaxDelay
+	if maxDelay == 0 {
+		maxDelay = defaultBudgetMaxDelay
+	}
+
+	usage := b.Usage()
+	if usage <= threshold {
+		return 0
+	}
+	if usage >= 100 || threshold >= 100 {
+		return maxDelay
+	}
+	return maxDelay * time.Duration(usage-threshold) / time.Duration(100-threshold)
+}
+
+// Wait for the Delay, or until the context is done in which case its error is
+// returned.
+func (b *BudgetTracker) wait(ctx context.Context) error {
+	d := b.Delay()
+	if d <= 0 {
+		return nil
+	}
+	return sleep(ctx, d)
+}
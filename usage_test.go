@@ -0,0 +1,180 @@
+package fbapi_test
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/facebookgo/ensure"
+	"github.com/facebookgo/fbapi"
+)
+
+func appUsageResponse(usage string) *http.Response {
+	h := make(http.Header)
+	if usage != "" {
+		h.Set("X-App-Usage", usage)
+	}
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     h,
+		Body:       ioutil.NopCloser(strings.NewReader("{}")),
+	}
+}
+
+func TestBudgetTracker(t *testing.T) {
+	t.Parallel()
+	b := &fbapi.BudgetTracker{MaxDelay: time.Minute}
+	ensure.DeepEqual(t, b.Usage(), 0)
+	ensure.DeepEqual(t, b.Delay(), time.Duration(0))
+
+	b.Observe(appUsageResponse(`{"call_count":10,"total_time":5,"total_cputime":3}`))
+	ensure.DeepEqual(t, b.Usage(), 10)
+	ensure.DeepEqual(t, b.Delay(), time.Duration(0))
+
+	b.Observe(appUsageResponse(`{"call_count":40,"total_time":95,"total_cputime":60}`))
+	b.Observe(appUsageResponse(`{"call_count":20,"total_time":30,"total_cputime":10}`))
+	b.Observe(appUsageResponse(""))
+	b.Observe(appUsageResponse("garbage"))
+	ensure.DeepEqual(t, b.Usage(), 95)
+	ensure.DeepEqual(t, b.Delay(), 48*time.Second)
+
+	b.Observe(appUsageResponse(`{"call_count":100,"total_time":30,"total_cputime":10}`))
+	ensure.DeepEqual(t, b.Usage(), 100)
+	ensure.DeepEqual(t, b.Delay(), time.Minute)
+}
+
+func TestBudgetTrackerWindow(t *testing.T) {
+	t.Parallel()
+	b := &fbapi.BudgetTracker{Window: time.Millisecond}
+	b.Observe(appUsageResponse(`{"call_count":99}`))
+	ensure.DeepEqual(t, b.Usage(), 99)
+	time.Sleep(5 * time.Millisecond)
+	ensure.DeepEqual(t, b.Usage(), 0)
+}
+
+func TestClientBudget(t *testing.T) {
+	t.Parallel()
+	b := &fbapi.BudgetTracker{}
+	c := &fbapi.Client{
+		Budget: b,
+		Transport: fTransport(func(r *http.Request) (*http.Response, error) {
+			return appUsageResponse(`{"call_count":42,"total_time":7,"total_cputime":1}`), nil
+		}),
+	}
+	_, err := c.Do(&http.Request{Method: "GET"}, nil)
+	ensure.Nil(t, err)
+	ensure.DeepEqual(t, b.Usage(), 42)
+}
+
+// Returns a client with the given budget responding with the given usage
+// headers in order, along with the times the requests were sent.
+func budgetClient(b *fbapi.BudgetTracker, usages ...string) (*fbapi.Client, *[]time.Time) {
+	var sent []time.Time
+	return &fbapi.Client{
+		Budget: b,
+		Transport: fTransport(func(r *http.Request) (*http.Response, error) {
+			res := appUsageResponse(usages[len(sent)])
+			sent = append(sent, time.Now())
+			return res, nil
+		}),
+	}, &sent
+}
+
+func TestClientBudgetWait(t *testing.T) {
+	t.Parallel()
+	const maxDelay = 50 * time.Millisecond
+	c, sent := budgetClient(
+		&fbapi.BudgetTracker{Threshold: 90, MaxDelay: maxDelay, Window: 40 * time.Millisecond, Wait: true},
+		`{"call_count":100}`, `{"call_count":10}`, "")
+	for i := 0; i < 3; i++ {
+		_, err := c.Do(&http.Request{Method: "GET"}, nil)
+		ensure.Nil(t, err)
+	}
+	ensure.True(t, (*sent)[1].Sub((*sent)[0]) >= maxDelay, *sent)
+	ensure.True(t, (*sent)[2].Sub((*sent)[1]) < maxDelay, *sent)
+}
+
+func TestClientBudgetNoWait(t *testing.T) {
+	t.Parallel()
+	c, sent := budgetClient(&fbapi.BudgetTracker{MaxDelay: time.Hour},
+		`{"call_count":100}`, "")
+	for i := 0; i < 2; i++ {
+		_, err := c.Do(&http.Request{Method: "GET"}, nil)
+		ensure.Nil(t, err)
+	}
+	ensure.DeepEqual(t, len(*sent), 2)
+}
+
+func TestClientBudgetWaitContext(t *testing.T) {
+	t.Parallel()
+	c, _ := budgetClient(&fbapi.BudgetTracker{MaxDelay: time.Hour, Wait: true},
+		`{"call_count":100}`)
+	_, err := c.Do(&http.Request{Method: "GET"}, nil)
+	ensure.Nil(t, err)
+
+	// the next request waits for an hour, so only the context ends it
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = c.DoContext(ctx, &http.Request{Method: "GET"}, nil)
+	ensure.DeepEqual(t, err, context.DeadlineExceeded)
+}
+
+func TestClientBudgetRetryAfter(t *testing.T) {
+	t.Parallel()
+	var calls int
+	b := &fbapi.BudgetTracker{Wait: true}
+	c := &fbapi.Client{
+		Budget: b,
+		Transport: fTransport(func(r *http.Request) (*http.Response, error) {
+			calls++
+			return &http.Response{
+				StatusCode: http.StatusServiceUnavailable,
+				Header:     http.Header{"Retry-After": []string{"3600"}},
+				Body:       ioutil.NopCloser(strings.NewReader(`{"error":{"code":2}}`)),
+			}, nil
+		}),
+	}
+	_, err := c.Do(&http.Request{Method: "GET"}, nil)
+	ensure.DeepEqual(t, withoutResponse(err), &fbapi.Error{Code: 2, RetryAfter: time.Hour})
+	ensure.True(t, b.Delay() > 59*time.Minute, b.Delay())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = c.DoContext(ctx, &http.Request{Method: "GET"}, nil)
+	ensure.DeepEqual(t, err, context.DeadlineExceeded)
+	ensure.DeepEqual(t, calls, 1)
+}
+
+func TestParseAppUsage(t *testing.T) {
+	t.Parallel()
+	u, err := fbapi.ParseAppUsage(appUsageResponse(`{"call_count":28,"total_time":25,"total_cputime":25}`))
+	ensure.Nil(t, err)
+	ensure.DeepEqual(t, u, &fbapi.AppUsage{CallCount: 28, TotalTime: 25, TotalCPUTime: 25})
+	ensure.DeepEqual(t, u.Max(), 28)
+
+	u, err = fbapi.ParseAppUsage(appUsageResponse(""))
+	ensure.Nil(t, err)
+	ensure.True(t, u == nil)
+
+	u, err = fbapi.ParseAppUsage(appUsageResponse(`{"call_count":"lots"}`))
+	ensure.NotNil(t, err)
+	ensure.True(t, u == nil)
+}
+
+func TestMalformedAppUsageDoesNotFailRequest(t *testing.T) {
+	t.Parallel()
+	c := &fbapi.Client{
+		Budget: &fbapi.BudgetTracker{},
+		Transport: fTransport(func(r *http.Request) (*http.Response, error) {
+			return appUsageResponse("{malformed"), nil
+		}),
+	}
+	res, err := c.Do(&http.Request{Method: "GET"}, nil)
+	ensure.Nil(t, err)
+	u, err := fbapi.ParseAppUsage(res)
+	ensure.NotNil(t, err)
+	ensure.True(t, u == nil)
+}
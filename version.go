@@ -0,0 +1,33 @@
+package fbapi
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+var versionPath = regexp.MustCompile(`^/?v\d+\.\d+(/|$)`)
+
+// Returns the relative URL with its path prefixed by the Version, unless it
+// already includes one.
+func (c *Client) versioned(u *url.URL) *url.URL {
+	if c.Version == "" || versionPath.MatchString(u.Path) {
+		return u
+	}
+	version := c.Version
+	if !strings.HasPrefix(version, "v") {
+		version = "v" + version
+	}
+	prefix := func(p string) string {
+		if strings.HasPrefix(p, "/") {
+			return "/" + version + p
+		}
+		return version + "/" + p
+	}
+	v := *u
+	v.Path = prefix(u.Path)
+	if u.RawPath != "" {
+		v.RawPath = prefix(u.RawPath)
+	}
+	return &v
+}
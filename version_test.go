@@ -0,0 +1,63 @@
+package fbapi_test
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/facebookgo/ensure"
+	"github.com/facebookgo/fbapi"
+)
+
+func TestVersion(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		Version  string
+		BaseURL  string
+		URL      string
+		Expected string
+	}{
+		{Version: "v2.5", URL: "me", Expected: "https://graph.facebook.com/v2.5/me"},
+		{Version: "2.5", URL: "/me", Expected: "https://graph.facebook.com/v2.5/me"},
+		{Version: "v2.5", URL: "/v2.4/me", Expected: "https://graph.facebook.com/v2.4/me"},
+		{Version: "v2.5", URL: "https://example.com/me", Expected: "https://example.com/me"},
+		{Version: "v2.5", BaseURL: "https://example.com/proxy/", URL: "me", Expected: "https://example.com/proxy/v2.5/me"},
+		{URL: "me", Expected: "https://graph.facebook.com/me"},
+		{Version: "v3.1", URL: "me/posts", Expected: "https://graph.facebook.com/v3.1/me/posts"},
+		{Version: "v3.1", URL: "v3.0/me", Expected: "https://graph.facebook.com/v3.0/me"},
+		{Version: "v3.1", URL: "/v3.0", Expected: "https://graph.facebook.com/v3.0"},
+		{Version: "v3.1", URL: "/v12.0/me?fields=id", Expected: "https://graph.facebook.com/v12.0/me?fields=id"},
+		{Version: "v3.1", URL: "videos", Expected: "https://graph.facebook.com/v3.1/videos"},
+		{Version: "v3.1", URL: "/v3.0x/me", Expected: "https://graph.facebook.com/v3.1/v3.0x/me"},
+		{
+			Version:  "v3.1",
+			URL:      "https://graph.facebook.com/v2.8/me/posts?after=abc",
+			Expected: "https://graph.facebook.com/v2.8/me/posts?after=abc",
+		},
+		{
+			Version:  "v3.1",
+			URL:      "https://graph.facebook.com/me/posts?after=abc",
+			Expected: "https://graph.facebook.com/me/posts?after=abc",
+		},
+	}
+	for _, tc := range cases {
+		givenErr := errors.New("")
+		c := &fbapi.Client{
+			Version: tc.Version,
+			Transport: fTransport(func(r *http.Request) (*http.Response, error) {
+				ensure.DeepEqual(t, r.URL.String(), tc.Expected)
+				return nil, givenErr
+			}),
+		}
+		if tc.BaseURL != "" {
+			u, err := url.Parse(tc.BaseURL)
+			ensure.Nil(t, err)
+			c.BaseURL = u
+		}
+		u, err := url.Parse(tc.URL)
+		ensure.Nil(t, err)
+		_, err = c.Do(&http.Request{Method: "GET", URL: u}, nil)
+		ensure.True(t, err == givenErr, err)
+	}
+}
@@ -0,0 +1,254 @@
+package fbapi
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// VideoUpload is a resumable video upload session. The offsets specify the
+// next chunk the API expects, the upload is complete once they are equal.
+//
+// For the official documentation look at:
+// https://developers.facebook.com/docs/graph-api/video-uploads
+type VideoUpload struct {
+	// The path the video is being uploaded to, typically /{id}/videos. Note
+	// that videos should be uploaded to https://graph-video.facebook.com/, so
+	// you probably want to use an absolute URL here.
+	Path string `json:"-"`
+
+	SessionID   string `json:"upload_session_id"`
+	VideoID     string `json:"video_id"`
+	StartOffset int64  `json:"start_offset,string"`
+	EndOffset   int64  `json:"end_offset,string"`
+}
+
+// StartVideoUpload starts a resumable upload session for a video of the given
+// size in bytes.
+func (c *Client) StartVideoUpload(ctx context.Context, path string, size int64, params ...Param) (*VideoUpload, error) {
+	v, err := ParamValues(params...)
+	if err != nil {
+		return nil, err
+	}
+	v.Set("upload_phase", "start")
+	v.Set("file_size", strconv.FormatInt(size, 10))
+
+	req, err := http.NewRequest("POST", path, strings.NewReader(v.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	u := &VideoUpload{Path: path}
+	if _, err := c.DoContext(ctx, req, u); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+// TransferVideoChunk transfers the chunk starting at u.StartOffset and updates
+// the offsets in u to those of the next chunk expected by the API.
+func (c *Client) TransferVideoChunk(ctx context.Context, u *VideoUpload, chunk io.Reader, params ...Param) error {
+	v, err := ParamValues(params...)
+	if err != nil {
+		return err
+	}
+	v.Set("upload_phase", "transfer")
+	v.Set("upload_session_id", u.SessionID)
+	v.Set("start_offset", strconv.FormatInt(u.StartOffset, 10))
+
+	req, err := newMultipartRequest(u.Path, v, "video_file_chunk", "chunk", chunk)
+	if err != nil {
+		return err
+	}
+
+	var offsets struct {
+		StartOffset int64 `json:"start_offset,string"`
+		EndOffset   int64 `json:"end_offset,string"`
+	}
+	if _, err := c.DoContext(ctx, req, &offsets); err != nil {
+		return err
+	}
+	u.StartOffset = offsets.StartOffset
+	u.EndOffset = offsets.EndOffset
+	return nil
+}
+
+// FinishVideoUpload completes the upload session. Params such as the title and
+// description of the video are typically sent here.
+func (c *Client) FinishVideoUpload(ctx context.Context, u *VideoUpload, params ...Param) error {
+	v, err := ParamValues(params...)
+	if err != nil {
+		return err
+	}
+	v.Set("upload_phase", "finish")
+	v.Set("upload_session_id", u.SessionID)
+
+	req, err := http.NewRequest("POST", u.Path, strings.NewReader(v.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	_, err = c.DoContext(ctx, req, nil)
+	return err
+}
+
+// UploadVideo uploads the video of the given size read from r using a
+// resumable upload session, transferring the chunks requested by the API as
+// they are read. The params are sent with every phase. As r is only read once,
+// failed chunk transfers aren't retried, use a VideoUploader for that.
+func (c *Client) UploadVideo(ctx context.Context, path string, r io.Reader, size int64, params ...Param) (*VideoUpload, error) {
+	v := &VideoUploader{
+		Client:        c,
+		Path:          path,
+		Params:        params,
+		ChunkAttempts: 1,
+	}
+	return v.Upload(ctx, &forwardReaderAt{r: r}, size)
+}
+
+// An io.ReaderAt for an io.Reader, supporting reads at increasing offsets
+// only. Bytes before the offset of a read are skipped.
+type forwardReaderAt struct {
+	r      io.Reader
+	offset int64
+}
+
+func (f *forwardReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	if off < f.offset {
+		return 0, fmt.Errorf(
+			"fbapi: video upload requested offset %d after %d was read",
+			off, f.offset)
+	}
+	if _, err := io.CopyN(ioutil.Discard, f.r, off-f.offset); err != nil {
+		return 0, err
+	}
+	f.offset = off
+	n, err := io.ReadFull(f.r, p)
+	f.offset += int64(n)
+	if err == io.ErrUnexpectedEOF {
+		err = io.EOF
+	}
+	return n, err
+}
+
+const (
+	defaultVideoChunkAttempts   = 3
+	defaultVideoChunkRetryDelay = time.Second
+)
+
+// VideoUploader drives resumable video upload sessions. As the video is read
+// from an io.ReaderAt, failed chunk transfers are retried without restarting
+// the session.
+type VideoUploader struct {
+	Client *Client
+
+	// The path the video is uploaded to, typically
+	// https://graph-video.facebook.com/{id}/videos.
+	Path string
+
+	// When set, this is sent as the access_token parameter of every phase.
+	AccessToken string
+
+	// Sent with every phase, for example the title and description.
+	Params []Param
+
+	// Maximum number of attempts to transfer each chunk, including the first
+	// one. Only transient failures are retried, as with the Client Retry.
+	// Defaults to 3.
+	ChunkAttempts int
+
+	// The delay before retrying a chunk transfer. Defaults to 1s.
+	ChunkRetryDelay time.Duration
+
+	// When set, it is called after each chunk is transferred with the number
+	// of bytes uploaded so far and the size of the video.
+	Progress func(uploaded, size int64)
+}
+
+// Upload the video of the given size read from r.
+func (v *VideoUploader) Upload(ctx context.Context, r io.ReaderAt, size int64) (*VideoUpload, error) {
+	params := v.Params
+	if v.AccessToken != "" {
+		params = append(params[:len(params):len(params)], ParamAccessToken(v.AccessToken))
+	}
+	attempts := v.ChunkAttempts
+	if attempts == 0 {
+		attempts = defaultVideoChunkAttempts
+	}
+	retryDelay := v.ChunkRetryDelay
+	if retryDelay == 0 {
+		retryDelay = defaultVideoChunkRetryDelay
+	}
+
+	u, err := v.Client.StartVideoUpload(ctx, v.Path, size, params...)
+	if err != nil {
+		return nil, err
+	}
+	for u.StartOffset < u.EndOffset {
+		for attempt := 1; ; attempt++ {
+			chunk := io.NewSectionReader(r, u.StartOffset, u.EndOffset-u.StartOffset)
+			err = v.Client.TransferVideoChunk(ctx, u, chunk, params...)
+			if err == nil || attempt >= attempts || ctx.Err() != nil || !transient(errorResponse(err), err) {
+				break
+			}
+			if err := sleep(ctx, retryDelay); err != nil {
+				return nil, err
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+		if v.Progress != nil {
+			v.Progress(u.StartOffset, size)
+		}
+	}
+
+	if err := v.Client.FinishVideoUpload(ctx, u, params...); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+// Make a multipart POST request with the given values and a single file part.
+// The file is streamed from r rather than buffered.
+func newMultipartRequest(path string, v url.Values, field, filename string, r io.Reader) (*http.Request, error) {
+	pr, pw := io.Pipe()
+	mw := multipart.NewWriter(pw)
+	go func() {
+		for key, values := range v {
+			for _, value := range values {
+				if err := mw.WriteField(key, value); err != nil {
+					pw.CloseWithError(err)
+					return
+				}
+			}
+		}
+		fw, err := mw.CreateFormFile(field, filename)
+		if err != nil {
+			pw.CloseWithError(err)
+			return
+		}
+		if _, err := io.Copy(fw, r); err != nil {
+			pw.CloseWithError(err)
+			return
+		}
+		pw.CloseWithError(mw.Close())
+	}()
+
+	req, err := http.NewRequest("POST", path, pr)
+	if err != nil {
+		pr.Close()
+		return nil, err
+	}
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	return req, nil
+}
@@ -0,0 +1,237 @@
+package fbapi_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/facebookgo/ensure"
+	"github.com/facebookgo/fbapi"
+)
+
+func TestUploadVideo(t *testing.T) {
+	t.Parallel()
+	const (
+		path      = "https://graph-video.facebook.com/42/videos"
+		sessionID = "session42"
+		video     = "0123456789"
+	)
+	var phases []string
+	c := &fbapi.Client{
+		Transport: fTransport(func(r *http.Request) (*http.Response, error) {
+			ensure.DeepEqual(t, r.URL.String(), path)
+			if err := r.ParseMultipartForm(1024); err != http.ErrNotMultipart {
+				ensure.Nil(t, err)
+			}
+			ensure.DeepEqual(t, r.FormValue("access_token"), "at")
+			phase := r.FormValue("upload_phase")
+			phases = append(phases, phase)
+			var body string
+			switch phase {
+			case "start":
+				ensure.DeepEqual(t, r.FormValue("file_size"), fmt.Sprint(len(video)))
+				body = `{"upload_session_id":"session42","video_id":"v42","start_offset":"0","end_offset":"4"}`
+			case "transfer":
+				ensure.DeepEqual(t, r.FormValue("upload_session_id"), sessionID)
+				f, _, err := r.FormFile("video_file_chunk")
+				ensure.Nil(t, err)
+				chunk, err := ioutil.ReadAll(f)
+				ensure.Nil(t, err)
+				start := r.FormValue("start_offset")
+				switch start {
+				case "0":
+					ensure.DeepEqual(t, string(chunk), "0123")
+					body = `{"start_offset":"4","end_offset":"8"}`
+				case "4":
+					ensure.DeepEqual(t, string(chunk), "4567")
+					body = `{"start_offset":"8","end_offset":"10"}`
+				case "8":
+					ensure.DeepEqual(t, string(chunk), "89")
+					body = `{"start_offset":"10","end_offset":"10"}`
+				default:
+					t.Fatalf("unexpected start_offset %s", start)
+				}
+			case "finish":
+				ensure.DeepEqual(t, r.FormValue("upload_session_id"), sessionID)
+				body = `{"success":true}`
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(strings.NewReader(body)),
+			}, nil
+		}),
+	}
+	u, err := c.UploadVideo(
+		context.Background(),
+		path,
+		bytes.NewReader([]byte(video)),
+		int64(len(video)),
+		fbapi.ParamAccessToken("at"),
+	)
+	ensure.Nil(t, err)
+	ensure.DeepEqual(t, phases, []string{"start", "transfer", "transfer", "transfer", "finish"})
+	ensure.DeepEqual(t, u, &fbapi.VideoUpload{
+		Path:        path,
+		SessionID:   sessionID,
+		VideoID:     "v42",
+		StartOffset: 10,
+		EndOffset:   10,
+	})
+}
+
+func TestUploadVideoOffsets(t *testing.T) {
+	t.Parallel()
+	var chunks []string
+	c := &fbapi.Client{
+		Transport: fTransport(func(r *http.Request) (*http.Response, error) {
+			// the body fails to stream when the offset can't be read
+			if err := r.ParseMultipartForm(1024); err != nil && err != http.ErrNotMultipart {
+				return nil, err
+			}
+			var body string
+			switch r.FormValue("upload_phase") {
+			case "start":
+				body = `{"upload_session_id":"s","start_offset":"0","end_offset":"4"}`
+			case "transfer":
+				f, _, err := r.FormFile("video_file_chunk")
+				ensure.Nil(t, err)
+				chunk, err := ioutil.ReadAll(f)
+				ensure.Nil(t, err)
+				chunks = append(chunks, string(chunk))
+				switch r.FormValue("start_offset") {
+				case "0":
+					body = `{"start_offset":"6","end_offset":"10"}`
+				case "6":
+					body = `{"start_offset":"2","end_offset":"4"}`
+				}
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(strings.NewReader(body)),
+			}, nil
+		}),
+	}
+	_, err := c.UploadVideo(context.Background(), "42/videos", strings.NewReader("0123456789"), 10)
+	ensure.Err(t, err, regexp.MustCompile("requested offset 2 after 10 was read"))
+	ensure.DeepEqual(t, chunks, []string{"0123", "6789"})
+}
+
+func TestVideoUploader(t *testing.T) {
+	t.Parallel()
+	const (
+		path  = "https://graph-video.facebook.com/42/videos"
+		video = "0123456789"
+	)
+	var phases []string
+	var failed bool
+	c := &fbapi.Client{
+		Transport: fTransport(func(r *http.Request) (*http.Response, error) {
+			defer r.Body.Close()
+			if err := r.ParseMultipartForm(1024); err != http.ErrNotMultipart {
+				ensure.Nil(t, err)
+			}
+			ensure.DeepEqual(t, r.FormValue("access_token"), "at")
+			ensure.DeepEqual(t, r.FormValue("title"), "Title")
+			phase := r.FormValue("upload_phase")
+			phases = append(phases, phase)
+			var body string
+			switch phase {
+			case "start":
+				body = `{"upload_session_id":"s","video_id":"v42","start_offset":"0","end_offset":"6"}`
+			case "transfer":
+				f, _, err := r.FormFile("video_file_chunk")
+				ensure.Nil(t, err)
+				chunk, err := ioutil.ReadAll(f)
+				ensure.Nil(t, err)
+				switch r.FormValue("start_offset") {
+				case "0":
+					ensure.DeepEqual(t, string(chunk), "012345")
+					body = `{"start_offset":"6","end_offset":"10"}`
+				case "6":
+					ensure.DeepEqual(t, string(chunk), "6789")
+					if !failed {
+						failed = true
+						return nil, errors.New("connection reset")
+					}
+					body = `{"start_offset":"10","end_offset":"10"}`
+				}
+			case "finish":
+				body = `{"success":true}`
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(strings.NewReader(body)),
+			}, nil
+		}),
+	}
+	var progress []int64
+	uploader := &fbapi.VideoUploader{
+		Client:          c,
+		Path:            path,
+		AccessToken:     "at",
+		Params:          []fbapi.Param{fbapi.ParamString("title", "Title")},
+		ChunkRetryDelay: time.Millisecond,
+		Progress: func(uploaded, size int64) {
+			ensure.DeepEqual(t, size, int64(len(video)))
+			progress = append(progress, uploaded)
+		},
+	}
+	u, err := uploader.Upload(context.Background(), strings.NewReader(video), int64(len(video)))
+	ensure.Nil(t, err)
+	ensure.DeepEqual(t, phases, []string{"start", "transfer", "transfer", "transfer", "finish"})
+	ensure.DeepEqual(t, progress, []int64{6, 10})
+	ensure.DeepEqual(t, u.VideoID, "v42")
+}
+
+func TestVideoUploaderChunkFails(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		Code      int
+		Body      string
+		Transfers int
+		Err       *fbapi.Error
+	}{
+		{Code: http.StatusServiceUnavailable, Body: `{"error":{"code":2}}`, Transfers: 2, Err: &fbapi.Error{Code: 2}},
+		{Code: http.StatusBadRequest, Body: `{"error":{"code":190}}`, Transfers: 1, Err: &fbapi.Error{Code: 190}},
+		{Code: http.StatusBadRequest, Body: `{"error":{"code":6000}}`, Transfers: 1, Err: &fbapi.Error{Code: 6000}},
+	}
+	for _, tc := range cases {
+		var transfers int
+		c := &fbapi.Client{
+			Transport: fTransport(func(r *http.Request) (*http.Response, error) {
+				defer r.Body.Close()
+				if err := r.ParseMultipartForm(1024); err != http.ErrNotMultipart {
+					ensure.Nil(t, err)
+				}
+				if r.FormValue("upload_phase") == "start" {
+					return &http.Response{
+						StatusCode: http.StatusOK,
+						Body: ioutil.NopCloser(strings.NewReader(
+							`{"upload_session_id":"s","start_offset":"0","end_offset":"2"}`)),
+					}, nil
+				}
+				transfers++
+				return &http.Response{
+					StatusCode: tc.Code,
+					Body:       ioutil.NopCloser(strings.NewReader(tc.Body)),
+				}, nil
+			}),
+		}
+		uploader := &fbapi.VideoUploader{
+			Client:          c,
+			Path:            "42/videos",
+			ChunkAttempts:   2,
+			ChunkRetryDelay: time.Millisecond,
+		}
+		_, err := uploader.Upload(context.Background(), strings.NewReader("01"), 2)
+		ensure.DeepEqual(t, withoutResponse(err), tc.Err)
+		ensure.DeepEqual(t, transfers, tc.Transfers, tc.Body)
+	}
+}
@@ -0,0 +1,68 @@
+package fbapi
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"hash"
+	"net/url"
+	"strings"
+)
+
+var (
+	// ErrInvalidWebhookSignature is returned by VerifyWebhookSignature when the
+	// signature is missing, malformed or doesn't match the body.
+	ErrInvalidWebhookSignature = errors.New("fbapi: invalid webhook signature")
+
+	// ErrInvalidWebhookChallenge is returned by VerifyWebhookChallenge when the
+	// request isn't a subscription verification or the verify token doesn't
+	// match.
+	ErrInvalidWebhookChallenge = errors.New("fbapi: invalid webhook challenge")
+)
+
+// VerifyWebhookSignature checks the signature of a webhook request body, given
+// the value of the X-Hub-Signature-256 header, or the X-Hub-Signature one,
+// which are prefixed by sha256= and sha1= respectively. The body must be the
+// raw request body, as the signature is the HMAC of it keyed by the app
+// secret.
+func VerifyWebhookSignature(header string, body []byte, appSecret string) error {
+	var h func() hash.Hash
+	var hexSig string
+	switch {
+	case strings.HasPrefix(header, "sha256="):
+		h, hexSig = sha256.New, strings.TrimPrefix(header, "sha256=")
+	case strings.HasPrefix(header, "sha1="):
+		h, hexSig = sha1.New, strings.TrimPrefix(header, "sha1=")
+	default:
+		return ErrInvalidWebhookSignature
+	}
+	sig, err := hex.DecodeString(hexSig)
+	if err != nil {
+		return ErrInvalidWebhookSignature
+	}
+
+	mac := hmac.New(h, []byte(appSecret))
+	mac.Write(body)
+	if !hmac.Equal(sig, mac.Sum(nil)) {
+		return ErrInvalidWebhookSignature
+	}
+	return nil
+}
+
+// VerifyWebhookChallenge handles the verification request made when
+// subscribing to webhooks, given its query values. It checks the hub.mode and
+// that the hub.verify_token matches the one configured for the subscription,
+// returning the hub.challenge which must be echoed back as the response body.
+func VerifyWebhookChallenge(values url.Values, verifyToken string) (string, error) {
+	if values.Get("hub.mode") != "subscribe" {
+		return "", ErrInvalidWebhookChallenge
+	}
+	token := values.Get("hub.verify_token")
+	if subtle.ConstantTimeCompare([]byte(token), []byte(verifyToken)) != 1 {
+		return "", ErrInvalidWebhookChallenge
+	}
+	return values.Get("hub.challenge"), nil
+}
@@ -0,0 +1,68 @@
+package fbapi_test
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"net/url"
+	"testing"
+
+	"github.com/facebookgo/ensure"
+	"github.com/facebookgo/fbapi"
+)
+
+func signWebhook(h func() hash.Hash, body []byte, secret string) string {
+	mac := hmac.New(h, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestVerifyWebhookSignature(t *testing.T) {
+	t.Parallel()
+	body := []byte(`{"object":"page","entry":[]}`)
+	ensure.Nil(t, fbapi.VerifyWebhookSignature(
+		"sha256="+signWebhook(sha256.New, body, "s3cret"), body, "s3cret"))
+	ensure.Nil(t, fbapi.VerifyWebhookSignature(
+		"sha1="+signWebhook(sha1.New, body, "s3cret"), body, "s3cret"))
+}
+
+func TestVerifyWebhookSignatureInvalid(t *testing.T) {
+	t.Parallel()
+	body := []byte(`{"object":"page","entry":[]}`)
+	for _, header := range []string{
+		"",
+		"sha256=" + signWebhook(sha256.New, body, "other"),
+		"sha1=" + signWebhook(sha1.New, []byte(`{}`), "s3cret"),
+		"sha1=" + signWebhook(sha256.New, body, "s3cret"),
+		"md5=" + signWebhook(sha256.New, body, "s3cret"),
+		"sha256=zz",
+	} {
+		err := fbapi.VerifyWebhookSignature(header, body, "s3cret")
+		ensure.True(t, err == fbapi.ErrInvalidWebhookSignature, header, err)
+	}
+}
+
+func TestVerifyWebhookChallenge(t *testing.T) {
+	t.Parallel()
+	challenge, err := fbapi.VerifyWebhookChallenge(url.Values{
+		"hub.mode":         {"subscribe"},
+		"hub.verify_token": {"token"},
+		"hub.challenge":    {"1158201444"},
+	}, "token")
+	ensure.Nil(t, err)
+	ensure.DeepEqual(t, challenge, "1158201444")
+}
+
+func TestVerifyWebhookChallengeInvalid(t *testing.T) {
+	t.Parallel()
+	for _, values := range []url.Values{
+		{"hub.mode": {"subscribe"}, "hub.verify_token": {"other"}, "hub.challenge": {"1"}},
+		{"hub.mode": {"unsubscribe"}, "hub.verify_token": {"token"}, "hub.challenge": {"1"}},
+		{"hub.challenge": {"1"}},
+	} {
+		_, err := fbapi.VerifyWebhookChallenge(values, "token")
+		ensure.True(t, err == fbapi.ErrInvalidWebhookChallenge, values, err)
+	}
+}